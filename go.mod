@@ -7,4 +7,5 @@ require (
 	github.com/hashicorp/terraform-plugin-sdk v1.10.0
 	github.com/mitchellh/mapstructure v1.1.2
 	github.com/terra-farm/udnssdk v1.3.5
+	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
 )
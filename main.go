@@ -6,6 +6,7 @@ import (
 )
 
 func main() {
+	defer ultradns.CloseMockServers()
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: ultradns.Provider})
 }
@@ -0,0 +1,126 @@
+package ultradns
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditLog appends a JSON line for every modifying API call to a file,
+// as evidence of what a run changed. Lines are only ever appended, so
+// the file collects the calls of successive runs, told apart by RunID.
+type auditLog struct {
+	path  string
+	runID string
+
+	mu sync.Mutex
+}
+
+// auditEntry is a line of the audit file
+type auditEntry struct {
+	Time      string `json:"time"`
+	RunID     string `json:"run_id"`
+	Operation string `json:"operation"`
+	Key       string `json:"key"`
+	// PayloadSHA256 is the hash of the request body, empty when it had none
+	PayloadSHA256 string `json:"payload_sha256,omitempty"`
+	Result        string `json:"result"`
+	Status        int    `json:"status,omitempty"`
+	Error         string `json:"error,omitempty"`
+
+	CorrelationID string `json:"correlation_id"`
+	RequestID     string `json:"request_id,omitempty"`
+	ChangeComment string `json:"change_comment,omitempty"`
+}
+
+// newAuditLog checks that path can be appended to before any call is
+// made, so that a run never modifies DNS without the evidence of it
+func newAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening the audit file failed: %v", err)
+	}
+	f.Close()
+	return &auditLog{path: path, runID: newCorrelationID()}, nil
+}
+
+// record appends the entry for a finished call
+func (a *auditLog) record(req *http.Request, payload []byte, resp *http.Response, err error, changeComment string) error {
+	e := auditEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		RunID:     a.runID,
+		Operation: req.Method,
+		Key:       req.URL.RequestURI(),
+		Result:    "success",
+
+		CorrelationID: req.Header.Get(correlationIDHeader),
+		ChangeComment: changeComment,
+	}
+	if resp != nil {
+		e.Status = resp.StatusCode
+		e.RequestID = resp.Header.Get(requestIDHeader)
+	}
+	return a.write(e, payload, err)
+}
+
+// recordBatchCall appends the entry for one call of a batch, resp being
+// the response to the whole batch, so that each RRSet written has its
+// own key and result
+func (a *auditLog) recordBatchCall(c batchCall, res batchResult, callErr error, resp *http.Response, changeComment string) error {
+	e := auditEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		RunID:     a.runID,
+		Operation: c.Method,
+		Key:       c.URI,
+		Result:    "success",
+		Status:    res.Code,
+
+		RequestID:     resp.Header.Get(requestIDHeader),
+		ChangeComment: changeComment,
+	}
+	if resp.Request != nil {
+		e.CorrelationID = resp.Request.Header.Get(correlationIDHeader)
+	}
+	var payload []byte
+	if c.Body != nil {
+		payload, _ = json.Marshal(c.Body)
+	}
+	return a.write(e, payload, callErr)
+}
+
+// write completes e with the hash of payload and the outcome of the
+// call, and appends it
+func (a *auditLog) write(e auditEntry, payload []byte, err error) error {
+	if len(payload) > 0 {
+		sum := sha256.Sum256(payload)
+		e.PayloadSHA256 = hex.EncodeToString(sum[:])
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err != nil || e.Status >= 400 {
+		e.Result = "failure"
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
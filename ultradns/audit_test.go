@@ -0,0 +1,111 @@
+package ultradns
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ultradns-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true, AuditFile: path, ChangeComment: "CHG-1"}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	key := udnssdk.RRSetKey{Zone: "example.com", Name: "www", Type: "A"}
+	rrset := udnssdk.RRSet{OwnerName: "www", RRType: "A", TTL: 300, RData: []string{"192.0.2.1"}}
+	if _, err := client.RRSets.Create(key, rrset); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := client.RRSets.Select(key); err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if _, err := client.RRSets.Create(key, rrset); err == nil {
+		t.Fatalf("second Create: expected an error")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit file has %d lines, want one per modifying call:\n%s", len(lines), b)
+	}
+	entries := make([]auditEntry, len(lines))
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entries[i]); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+	}
+
+	e := entries[0]
+	if e.Operation != "POST" || e.Key != "/v1/zones/example.com/rrsets/A/www" || e.Result != "success" || e.Status != 201 {
+		t.Errorf("first entry = %+v", e)
+	}
+	if len(e.PayloadSHA256) != 64 || e.CorrelationID == "" || e.ChangeComment != "CHG-1" || e.RunID == "" {
+		t.Errorf("first entry = %+v", e)
+	}
+	if e := entries[1]; e.Result != "failure" || e.PayloadSHA256 != entries[0].PayloadSHA256 || e.RunID != entries[0].RunID {
+		t.Errorf("second entry = %+v", e)
+	}
+
+	if _, err := (&Config{Username: t.Name(), Password: "pass", Mock: true, AuditFile: filepath.Join(dir, "missing", "audit.jsonl")}).Client(); err == nil {
+		t.Errorf("expected an unwritable audit file to fail")
+	}
+}
+
+func TestAuditLog_batch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ultradns-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true, AuditFile: path}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	taken := rRSetResource{OwnerName: "taken", RRType: "A", Zone: "example.com", TTL: 300, RData: []string{"192.0.2.9"}}
+	if err := createRRSet(client, taken, false); err != nil {
+		t.Fatalf("createRRSet: %v", err)
+	}
+	free := rRSetResource{OwnerName: "free", RRType: "A", Zone: "example.com", TTL: 300, RData: []string{"192.0.2.1"}}
+	errs, err := sendBatch(client, []batchCall{rRSetBatchCall("POST", taken), rRSetBatchCall("POST", free)})
+	if err != nil || errs[0] == nil || errs[1] != nil {
+		t.Fatalf("sendBatch: %v, %v", errs, err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("audit file has %d lines, want the create and one per batched call:\n%s", len(lines), b)
+	}
+	entries := make([]auditEntry, len(lines))
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entries[i]); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+	}
+	if e := entries[1]; e.Operation != "POST" || e.Key != "/v1/zones/example.com/rrsets/A/taken" || e.Result != "failure" || e.Status != 400 || e.Error == "" {
+		t.Errorf("failed call entry = %+v", e)
+	}
+	if e := entries[2]; e.Operation != "POST" || e.Key != "/v1/zones/example.com/rrsets/A/free" || e.Result != "success" || e.Status != 201 || len(e.PayloadSHA256) != 64 || e.CorrelationID == "" {
+		t.Errorf("successful call entry = %+v", e)
+	}
+}
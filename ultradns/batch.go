@@ -0,0 +1,101 @@
+package ultradns
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+// batchCall is one of the calls of a POST v1/batch request
+type batchCall struct {
+	Method string      `json:"method"`
+	URI    string      `json:"uri"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// batchResult is the response to one call of a batch, in the order of
+// the calls
+type batchResult struct {
+	Code int             `json:"code"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// rRSetBatchCall returns the call writing r with method: POST to create
+// it, PUT to replace it or DELETE to delete it
+func rRSetBatchCall(method string, r rRSetResource) batchCall {
+	c := batchCall{Method: method, URI: "/v1/" + r.RRSetKey().URI()}
+	if method != "DELETE" {
+		c.Body = r.RRSet()
+	}
+	return c
+}
+
+// sendBatch sends calls in one POST v1/batch request and returns the
+// error of each, nil where it succeeded. The API runs the calls in order
+// and does not undo those that succeeded when a later one fails.
+func sendBatch(client *Client, calls []batchCall) ([]error, error) {
+	errs := make([]error, len(calls))
+	if len(calls) == 0 {
+		return errs, nil
+	}
+
+	results := []batchResult{}
+	resp, err := client.Do("POST", "batch", calls, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(calls) {
+		err = fmt.Errorf("batch of %d calls answered with %d results", len(calls), len(results))
+	}
+	for i := range calls {
+		if err != nil {
+			errs[i] = err
+		} else {
+			errs[i] = batchCallError(calls[i], results[i])
+		}
+	}
+
+	// The transport leaves the calls of an answered batch to be audited
+	// here, each with its own key and result
+	if t := client.transport; t != nil && t.audit != nil {
+		for i, c := range calls {
+			res := batchResult{}
+			if i < len(results) {
+				res = results[i]
+			}
+			if aerr := t.audit.recordBatchCall(c, res, errs[i], resp, t.changeComment); aerr != nil {
+				log.Printf("[ERROR] writing %s %s to the audit file %s failed: %v", c.Method, c.URI, t.audit.path, aerr)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// batchCallError returns the error of a failed call as udnssdk would for
+// the same call made on its own, so that isRRSetNotFound and the like
+// recognise it
+func batchCallError(c batchCall, res batchResult) error {
+	if 200 <= res.Code && res.Code <= 299 {
+		return nil
+	}
+
+	u, _ := url.Parse(c.URI)
+	resp := &http.Response{StatusCode: res.Code, Request: &http.Request{Method: c.Method, URL: u}}
+	var ers []udnssdk.ErrorResponse
+	if err := json.Unmarshal(res.Body, &ers); err == nil && len(ers) > 0 {
+		return &udnssdk.ErrorResponseList{Response: resp, Responses: ers}
+	}
+	var er udnssdk.ErrorResponse
+	if err := json.Unmarshal(res.Body, &er); err == nil {
+		er.Response = resp
+		return er
+	}
+	return fmt.Errorf("%s %s: %d: %s", c.Method, c.URI, res.Code, res.Body)
+}
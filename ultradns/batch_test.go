@@ -0,0 +1,26 @@
+package ultradns
+
+import (
+	"testing"
+)
+
+func TestBatchCallError(t *testing.T) {
+	c := rRSetBatchCall("DELETE", rRSetResource{OwnerName: "0/25", Zone: "example.com", RRType: "PTR"})
+	if c.URI != "/v1/zones/example.com/rrsets/PTR/0%2F25" || c.Body != nil {
+		t.Errorf("rRSetBatchCall: got %+v", c)
+	}
+
+	if err := batchCallError(c, batchResult{Code: 204}); err != nil {
+		t.Errorf("204: got %v", err)
+	}
+	err := batchCallError(c, batchResult{Code: 404, Body: []byte(`[{"errorCode":70002,"errorMessage":"Data not found."}]`)})
+	if !isRRSetNotFound(err) {
+		t.Errorf("404: got %v, want the not found error", err)
+	}
+	if err != nil && err.Error() != "DELETE /v1/zones/example.com/rrsets/PTR/0%2F25: 404 70002 Data not found." {
+		t.Errorf("404: got message %q", err)
+	}
+	if err := batchCallError(c, batchResult{Code: 500, Body: []byte(`oops`)}); err == nil {
+		t.Error("500 with a body that is not JSON: expected an error")
+	}
+}
@@ -1,14 +1,21 @@
 package ultradns
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
 )
 
+// maxTTL is the largest TTL the API accepts, per RFC 2181
+const maxTTL = 2147483647
+
 // Conversion helper functions
 type rRSetResource struct {
 	OwnerName string
@@ -27,14 +34,73 @@ var profileAttrSchemaMap = map[string]udnssdk.ProfileSchema{
 	"tcpool_profile":  udnssdk.TCPoolSchema,
 }
 
+// rrTypeCodes are the RRTypes the API serves, with the numeric codes it
+// appends to them in responses, e.g. "A (1)"
+var rrTypeCodes = map[string]int{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"SOA":   6,
+	"PTR":   12,
+	"HINFO": 13,
+	"MX":    15,
+	"TXT":   16,
+	"RP":    17,
+	"AAAA":  28,
+	"SRV":   33,
+	"NAPTR": 35,
+	"CERT":  37,
+	"DNAME": 39,
+	"DS":    43,
+	"SSHFP": 44,
+	"TLSA":  52,
+	"SPF":   99,
+	"CAA":   257,
+}
+
+// poolProfileTypes maps each pool ProfileSchema URI onto a description of
+// the pool type and the resource that manages it, if any
+var poolProfileTypes = map[udnssdk.ProfileSchema][2]string{
+	udnssdk.DirPoolSchema: {"Directional pool", "ultradns_dirpool"},
+	udnssdk.RDPoolSchema:  {"Resource Distribution pool", "ultradns_rdpool"},
+	udnssdk.SBPoolSchema:  {"SiteBacker pool", ""},
+	udnssdk.TCPoolSchema:  {"Traffic Controller pool", "ultradns_tcpool"},
+}
+
+// describePoolProfile returns a description of the pool type of p that
+// suggests the resource to manage it with, or "" if p is not a pool
+func describePoolProfile(p udnssdk.RawProfile) string {
+	if p == nil {
+		return ""
+	}
+	// Read @context directly, as RawProfile.Context panics without it
+	c, _ := p["@context"].(string)
+	t, ok := poolProfileTypes[udnssdk.ProfileSchema(c)]
+	if !ok {
+		return fmt.Sprintf("pool with profile %q", c)
+	}
+	if t[1] == "" {
+		return fmt.Sprintf("%s, which this provider cannot manage", t[0])
+	}
+	return fmt.Sprintf("%s; manage it with %s instead", t[0], t[1])
+}
+
 func (r rRSetResource) RRSetKey() udnssdk.RRSetKey {
 	return udnssdk.RRSetKey{
 		Zone: r.Zone,
 		Type: r.RRType,
-		Name: r.OwnerName,
+		Name: escapeOwnerName(r.OwnerName),
 	}
 }
 
+// escapeOwnerName percent-encodes an owner name for an RRSetKey.
+// udnssdk puts RRSetKey.URI() in the request path as is, so a "/" in the
+// name would split the path and a "?" would start the query; the
+// transport sends the encoded segment through unchanged.
+func escapeOwnerName(name string) string {
+	return url.PathEscape(name)
+}
+
 func (r rRSetResource) RRSet() udnssdk.RRSet {
 	return udnssdk.RRSet{
 		OwnerName: r.OwnerName,
@@ -49,6 +115,130 @@ func (r rRSetResource) ID() string {
 	return fmt.Sprintf("%s.%s", r.OwnerName, r.Zone)
 }
 
+// createRRSet creates the RRSet described by r. If one already exists
+// at the same key, the create fails unless replaceExisting is set, in
+// which case the existing RRSet, plain or pool, is replaced with a
+// single PUT so the name keeps resolving during the conversion.
+func createRRSet(client *Client, r rRSetResource, replaceExisting bool) error {
+	if replaceExisting {
+		existing, err := client.RRSets.Select(r.RRSetKey())
+		if err != nil && !isRRSetNotFound(err) {
+			return err
+		}
+		if len(existing) > 0 {
+			log.Printf("[INFO] replacing existing RRSet %s %s: %+v", r.ID(), r.RRType, existing[0])
+			_, err = client.RRSets.Update(r.RRSetKey(), r.RRSet())
+			return err
+		}
+	}
+
+	_, err := client.RRSets.Create(r.RRSetKey(), r.RRSet())
+	if err != nil && !replaceExisting {
+		return explainCreateFailure(client, r, err)
+	}
+	return err
+}
+
+// explainCreateFailure adds to err, the failure to create r, the RRSet
+// already in its place, if there is one
+func explainCreateFailure(client *Client, r rRSetResource, err error) error {
+	existing, serr := client.RRSets.Select(r.RRSetKey())
+	if serr == nil && len(existing) > 0 {
+		if pool := describePoolProfile(existing[0].Profile); pool != "" {
+			return fmt.Errorf("%v: %s %s is a %s, or set replace_existing = true to replace it", err, r.ID(), r.RRType, pool)
+		}
+		return fmt.Errorf("%v: a %s RRSet already exists at %s; set replace_existing = true to replace it", err, r.RRType, r.ID())
+	}
+	return err
+}
+
+// isApexOwner reports whether the owner name refers to the zone apex,
+// given either relative ("@") or absolute ("example.com.") form
+func isApexOwner(ownerName, zone string) bool {
+	if ownerName == "" || ownerName == "@" {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSuffix(ownerName, "."), strings.TrimSuffix(zone, "."))
+}
+
+// fqdnOwner returns the absolute form of an owner name in zone
+func fqdnOwner(ownerName, zone string) string {
+	zone = strings.TrimSuffix(zone, ".") + "."
+	if isApexOwner(ownerName, zone) {
+		return zone
+	}
+	if strings.HasSuffix(ownerName, ".") {
+		return ownerName
+	}
+	return fmt.Sprintf("%s.%s", ownerName, zone)
+}
+
+// normalizeOwnerName returns an owner name in zone relative to it, so
+// that "www", "www.example.com" and "www.example.com." all name the same
+// RRSet. Names at the apex or outside zone are returned as given.
+func normalizeOwnerName(ownerName, zone string) string {
+	if isApexOwner(ownerName, zone) {
+		return ownerName
+	}
+	name := strings.TrimSuffix(ownerName, ".")
+	suffix := "." + strings.TrimSuffix(zone, ".")
+	if len(name) > len(suffix) && strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+		return name[:len(name)-len(suffix)]
+	}
+	return ownerName
+}
+
+// suppressOwnerNameDiff suppresses the diff between two ways of writing
+// the same owner name of the resource's zone
+func suppressOwnerNameDiff(k, old, new string, d *schema.ResourceData) bool {
+	if old == "" || new == "" {
+		return false
+	}
+	zone := d.Get("zone").(string)
+	if isApexOwner(old, zone) && isApexOwner(new, zone) {
+		return true
+	}
+	return strings.EqualFold(normalizeOwnerName(old, zone), normalizeOwnerName(new, zone))
+}
+
+// rrTypeName strips the numeric suffix the API adds to RRTypes in
+// responses, e.g. "A (1)" -> "A"
+func rrTypeName(rrtype string) string {
+	if i := strings.Index(rrtype, " ("); i > 0 {
+		return rrtype[:i]
+	}
+	return rrtype
+}
+
+// isRRSetNotFound reports whether err is the API's "no records" error,
+// which is also returned for missing probes
+func isRRSetNotFound(err error) bool {
+	// 70002 means Records Not Found
+	return hasErrorCode(err, 70002)
+}
+
+// isZoneNotFound reports whether err is the API's "zone does not exist"
+// error, e.g. when a zone was deleted outside Terraform
+func isZoneNotFound(err error) bool {
+	// 1801 means Zone does not exist in the system
+	return hasErrorCode(err, 1801)
+}
+
+// hasErrorCode reports whether err is an API error with the given code
+func hasErrorCode(err error, code int) bool {
+	switch e := err.(type) {
+	case *udnssdk.ErrorResponseList:
+		for _, r := range e.Responses {
+			if r.ErrorCode == code {
+				return true
+			}
+		}
+	case udnssdk.ErrorResponse:
+		return e.ErrorCode == code
+	}
+	return false
+}
+
 func unzipRdataHosts(configured []interface{}) []string {
 	hs := make([]string, 0, len(configured))
 	for _, rRaw := range configured {
@@ -76,18 +266,25 @@ func schemaPingProbe() *schema.Resource {
 				Type:     schema.TypeSet,
 				Optional: true,
 				Set:      hashLimits,
-				Elem:     resourceProbeLimits(),
+				Elem:     resourceProbeLimits(pingProbeLimitNames),
 			},
 		},
 	}
 }
 
-func resourceProbeLimits() *schema.Resource {
+// httpProbeLimitNames are the limits supported by HTTP probe transactions
+var httpProbeLimitNames = []string{"run", "avgRun", "connect", "avgConnect"}
+
+// pingProbeLimitNames are the limits supported by ping probes
+var pingProbeLimitNames = []string{"lossPercent", "total", "average", "run", "avgRun"}
+
+func resourceProbeLimits(names []string) *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(names, false),
 			},
 			"warning": {
 				Type:     schema.TypeInt,
@@ -138,7 +335,7 @@ func (p probeResource) ProbeInfoDTO() udnssdk.ProbeInfoDTO {
 func (p probeResource) Key() udnssdk.ProbeKey {
 	return udnssdk.ProbeKey{
 		Zone: p.Zone,
-		Name: p.Name,
+		Name: escapeOwnerName(p.Name),
 		ID:   p.ID,
 	}
 }
@@ -170,6 +367,31 @@ func makeSetFromLimits(ls map[string]udnssdk.ProbeDetailsLimitDTO) *schema.Set {
 	return s
 }
 
+// checkProbeLimits checks that the thresholds of each limit block
+// escalate, i.e. warning <= critical <= fail. Unset thresholds, which
+// are only possible in total_limits, are skipped.
+func checkProbeLimits(k string, limits []interface{}) error {
+	for _, limit := range limits {
+		l := limit.(map[string]interface{})
+		prev, prevName := 0, ""
+		for _, name := range []string{"warning", "critical", "fail"} {
+			v, _ := l[name].(int)
+			if v == 0 {
+				continue
+			}
+			if prevName != "" && v < prev {
+				prefix := k
+				if n, ok := l["name"].(string); ok {
+					prefix = fmt.Sprintf("%s %q", k, n)
+				}
+				return fmt.Errorf("%s: %s (%d) must not be less than %s (%d)", prefix, name, v, prevName, prev)
+			}
+			prev, prevName = v, name
+		}
+	}
+	return nil
+}
+
 func makeProbeDetailsLimit(configured interface{}) *udnssdk.ProbeDetailsLimitDTO {
 	l := configured.(map[string]interface{})
 	return &udnssdk.ProbeDetailsLimitDTO{
@@ -189,6 +411,105 @@ func makeSetFromStrings(ss []string) *schema.Set {
 	return st
 }
 
+// stringsFromList decodes a TypeList of strings into an []string
+func stringsFromList(l []interface{}) []string {
+	ss := make([]string, len(l))
+	for i, v := range l {
+		ss[i], _ = v.(string)
+	}
+	return ss
+}
+
+// normalizeTXTRdata strips the JSON string encoding the API wraps TXT
+// answers in, and any the configured value carries. This is their bug:
+// the same value can come back quoted once, quoted twice, or not at all.
+// A record of several character-strings is then rendered canonically by
+// joinTXTStrings.
+func normalizeTXTRdata(s string) string {
+	for len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		var u string
+		if err := json.Unmarshal([]byte(s), &u); err != nil {
+			break
+		}
+		s = u
+	}
+	return joinTXTStrings(splitTXTStrings(s))
+}
+
+// splitTXTStrings splits TXT rdata into its character-strings. Several
+// strings are written as in a zone file, each quoted and separated by
+// spaces, e.g. `"v=spf1 -all" "token"`; anything else is one string.
+func splitTXTStrings(rdata string) []string {
+	ss := []string{}
+	rest := strings.TrimSpace(rdata)
+	for rest != "" {
+		if rest[0] != '"' {
+			return []string{rdata}
+		}
+		var b strings.Builder
+		i, closed := 1, false
+		for ; i < len(rest); i++ {
+			c := rest[i]
+			if c == '\\' && i+1 < len(rest) {
+				i++
+				b.WriteByte(rest[i])
+				continue
+			}
+			if c == '"' {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return []string{rdata}
+		}
+		next := rest[i+1:]
+		if next != "" && next[0] != ' ' && next[0] != '\t' {
+			return []string{rdata}
+		}
+		ss = append(ss, b.String())
+		rest = strings.TrimSpace(next)
+	}
+	if len(ss) == 0 {
+		return []string{rdata}
+	}
+	return ss
+}
+
+// joinTXTStrings renders character-strings as TXT rdata: a single string
+// as is, several quoted and separated by spaces
+func joinTXTStrings(ss []string) string {
+	if len(ss) == 1 {
+		return ss[0]
+	}
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		s = strings.Replace(s, `\`, `\\`, -1)
+		quoted[i] = `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// hashRdataString hashes rdata as schema.HashString does, after TXT
+// normalization, so that a quoted and an unquoted TXT value are the same
+// set element. Other types never take the form it unquotes.
+func hashRdataString(v interface{}) int {
+	return schema.HashString(normalizeTXTRdata(v.(string)))
+}
+
+// suppressTXTRdataDiff suppresses the diff between rdata values of a TXT
+// record that only differ in JSON string encoding. The record's type is
+// the type attribute next to rdata, so k may be nested in a block.
+func suppressTXTRdataDiff(k, old, new string, d *schema.ResourceData) bool {
+	typeKey := "type"
+	if i := strings.LastIndex(k, ".rdata."); i >= 0 {
+		typeKey = k[:i] + ".type"
+	}
+	typ, _ := d.Get(typeKey).(string)
+	return strings.EqualFold(typ, "TXT") && normalizeTXTRdata(old) == normalizeTXTRdata(new)
+}
+
 // hashRdata generates a hashcode for an Rdata block
 func hashRdatas(v interface{}) int {
 	m := v.(map[string]interface{})
@@ -2,14 +2,17 @@ package ultradns
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
+	"testing"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/terra-farm/udnssdk"
 )
 
 func testAccRdpoolCheckDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*udnssdk.Client)
+	client := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "ultradns_rdpool" {
@@ -32,7 +35,7 @@ func testAccRdpoolCheckDestroy(s *terraform.State) error {
 }
 
 func testAccTcpoolCheckDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*udnssdk.Client)
+	client := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "ultradns_tcpool" {
@@ -66,7 +69,7 @@ func testAccCheckUltradnsRecordExists(n string, record *udnssdk.RRSet) resource.
 			return fmt.Errorf("No Record ID is set")
 		}
 
-		client := testAccProvider.Meta().(*udnssdk.Client)
+		client := testAccProvider.Meta().(*Client)
 		k := udnssdk.RRSetKey{
 			Zone: rs.Primary.Attributes["zone"],
 			Name: rs.Primary.Attributes["name"],
@@ -88,3 +91,173 @@ func testAccCheckUltradnsRecordExists(n string, record *udnssdk.RRSet) resource.
 		return nil
 	}
 }
+
+func TestIsApexOwner(t *testing.T) {
+	cases := []struct {
+		owner, zone string
+		want        bool
+	}{
+		{"@", "example.com.", true},
+		{"", "example.com.", true},
+		{"example.com.", "example.com.", true},
+		{"Example.COM", "example.com.", true},
+		{"child", "example.com.", false},
+		{"child.example.com.", "example.com.", false},
+	}
+
+	for _, c := range cases {
+		if got := isApexOwner(c.owner, c.zone); got != c.want {
+			t.Errorf("isApexOwner(%q, %q) = %v, want %v", c.owner, c.zone, got, c.want)
+		}
+	}
+}
+
+func TestFqdnOwner(t *testing.T) {
+	cases := []struct {
+		owner, zone, want string
+	}{
+		{"@", "example.com", "example.com."},
+		{"www", "example.com", "www.example.com."},
+		{"www", "example.com.", "www.example.com."},
+		{"www.example.com.", "example.com", "www.example.com."},
+	}
+
+	for _, c := range cases {
+		if got := fqdnOwner(c.owner, c.zone); got != c.want {
+			t.Errorf("fqdnOwner(%q, %q) = %q, want %q", c.owner, c.zone, got, c.want)
+		}
+	}
+}
+
+func TestRRTypeName(t *testing.T) {
+	for in, want := range map[string]string{"A (1)": "A", "TXT (16)": "TXT", "CNAME": "CNAME"} {
+		if got := rrTypeName(in); got != want {
+			t.Errorf("rrTypeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCheckProbeLimits(t *testing.T) {
+	limit := func(name string, w, c, f int) map[string]interface{} {
+		return map[string]interface{}{"name": name, "warning": w, "critical": c, "fail": f}
+	}
+	cases := []struct {
+		limits []interface{}
+		err    bool
+	}{
+		{[]interface{}{limit("run", 1, 2, 3)}, false},
+		{[]interface{}{limit("run", 2, 2, 2)}, false},
+		{[]interface{}{limit("run", 1, 2, 3), limit("connect", 3, 2, 1)}, true},
+		{[]interface{}{limit("run", 1, 3, 2)}, true},
+		// total_limits has no name and may leave thresholds unset
+		{[]interface{}{map[string]interface{}{"warning": 5, "critical": 0, "fail": 10}}, false},
+		{[]interface{}{map[string]interface{}{"warning": 5, "critical": 0, "fail": 4}}, true},
+	}
+
+	for i, c := range cases {
+		err := checkProbeLimits("limit", c.limits)
+		if (err != nil) != c.err {
+			t.Errorf("case %d: checkProbeLimits() error = %v, want error: %v", i, err, c.err)
+		}
+	}
+}
+
+func TestDescribePoolProfile(t *testing.T) {
+	if got := describePoolProfile(nil); got != "" {
+		t.Errorf("plain RRSet: got %q, want \"\"", got)
+	}
+
+	tc := udnssdk.RawProfile{"@context": string(udnssdk.TCPoolSchema)}
+	if got := describePoolProfile(tc); !strings.Contains(got, "ultradns_tcpool") {
+		t.Errorf("tcpool: got %q, want mention of ultradns_tcpool", got)
+	}
+
+	sb := udnssdk.RawProfile{"@context": string(udnssdk.SBPoolSchema)}
+	if got := describePoolProfile(sb); !strings.Contains(got, "cannot manage") {
+		t.Errorf("sbpool: got %q, want mention that it cannot be managed", got)
+	}
+}
+
+func TestIsZoneNotFound(t *testing.T) {
+	zoneGone := &udnssdk.ErrorResponseList{Responses: []udnssdk.ErrorResponse{{ErrorCode: 1801}}}
+	if !isZoneNotFound(zoneGone) || isRRSetNotFound(zoneGone) {
+		t.Errorf("1801: isZoneNotFound = %v, isRRSetNotFound = %v", isZoneNotFound(zoneGone), isRRSetNotFound(zoneGone))
+	}
+
+	recordsGone := &udnssdk.ErrorResponseList{Responses: []udnssdk.ErrorResponse{{ErrorCode: 70002}}}
+	if isZoneNotFound(recordsGone) || !isRRSetNotFound(recordsGone) {
+		t.Errorf("70002: isZoneNotFound = %v, isRRSetNotFound = %v", isZoneNotFound(recordsGone), isRRSetNotFound(recordsGone))
+	}
+
+	if isZoneNotFound(fmt.Errorf("boom")) {
+		t.Errorf("non-API error: isZoneNotFound = true")
+	}
+}
+
+func TestNormalizeTXTRdata(t *testing.T) {
+	cases := map[string]string{
+		`v=spf1 -all`:               `v=spf1 -all`,
+		`"v=spf1 -all"`:             `v=spf1 -all`,
+		`"\"v=spf1 -all\""`:         `v=spf1 -all`,
+		`"a\\b"`:                    `a\b`,
+		`"first" "second"`:          `"first" "second"`,
+		`"first"   "sec\"ond"`:      `"first" "sec\"ond"`,
+		`"\"first\" \"second\""`:    `"first" "second"`,
+		`0 issue "letsencrypt.org"`: `0 issue "letsencrypt.org"`,
+		`"`:                         `"`,
+	}
+	for in, want := range cases {
+		if got := normalizeTXTRdata(in); got != want {
+			t.Errorf("normalizeTXTRdata(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if hashRdataString(`"v=spf1 -all"`) != hashRdataString(`v=spf1 -all`) {
+		t.Errorf("hashRdataString: quoted and unquoted TXT values hash differently")
+	}
+}
+
+func TestSplitTXTStrings(t *testing.T) {
+	cases := []struct {
+		rdata string
+		want  []string
+	}{
+		{`v=spf1 -all`, []string{`v=spf1 -all`}},
+		{`"v=spf1 -all" "token=abc"`, []string{`v=spf1 -all`, `token=abc`}},
+		{`"a\"b" "c\\d"`, []string{`a"b`, `c\d`}},
+		{`"unterminated`, []string{`"unterminated`}},
+		{`"a"b`, []string{`"a"b`}},
+		{`"a" b`, []string{`"a" b`}},
+	}
+	for _, c := range cases {
+		if got := splitTXTStrings(c.rdata); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("splitTXTStrings(%q) = %q, want %q", c.rdata, got, c.want)
+		}
+	}
+
+	ss := []string{`v=spf1 -all`, `say "hi"`, `back\slash`}
+	if got := splitTXTStrings(joinTXTStrings(ss)); !reflect.DeepEqual(got, ss) {
+		t.Errorf("round-trip of %q: got %q", ss, got)
+	}
+}
+
+func TestNormalizeOwnerName(t *testing.T) {
+	cases := []struct {
+		owner, zone, want string
+	}{
+		{"www", "example.com", "www"},
+		{"www.example.com.", "example.com", "www"},
+		{"www.example.com", "example.com.", "www"},
+		{"a.b.Example.COM.", "example.com", "a.b"},
+		{"@", "example.com", "@"},
+		{"example.com.", "example.com", "example.com."},
+		{"www.example.net.", "example.com", "www.example.net."},
+		{"notexample.com", "example.com", "notexample.com"},
+	}
+
+	for _, c := range cases {
+		if got := normalizeOwnerName(c.owner, c.zone); got != c.want {
+			t.Errorf("normalizeOwnerName(%q, %q) = %q, want %q", c.owner, c.zone, got, c.want)
+		}
+	}
+}
@@ -3,26 +3,215 @@ package ultradns
 import (
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/terra-farm/udnssdk"
+	"golang.org/x/oauth2"
 )
 
 // Config collects the connection service-endpoint and credentials
 type Config struct {
 	Username string
 	Password string
-	BaseURL  string
+	// AccessToken and RefreshToken, if either is set, authenticate
+	// instead of Username and Password
+	AccessToken   string
+	RefreshToken  string
+	BaseURL       string
+	ChangeComment string
+	ReadOnly      bool
+	LogFormat     string
+	// MaxConsecutiveFailures trips the transport's circuit breaker
+	MaxConsecutiveFailures int
+	// Mock points the client at an in-memory simulation of the API
+	// instead of BaseURL
+	Mock bool
+	// MockStateFile, if set, keeps the zones of the Mock API between runs
+	MockStateFile string
+	// CheckZoneSerial enables the zone_serial check of RRSet resources
+	CheckZoneSerial bool
+	// FallbackBaseURLs are tried in order when BaseURL is unreachable at
+	// configure time; the endpoint chosen is kept for the run
+	FallbackBaseURLs []string
+	// TokenCacheFile, if set, keeps access tokens between runs
+	TokenCacheFile string
+	// DeniedRecordTypes are the RRTypes that may not be created
+	DeniedRecordTypes []string
+	// OwnerNamePolicies maps zones onto the patterns the names of the
+	// RRSets written in them must match
+	OwnerNamePolicies map[string][]string
+	// AuditFile, if set, receives a line for every modifying API call
+	AuditFile string
+}
+
+// endpointDialTimeout bounds the reachability check of each API endpoint
+const endpointDialTimeout = 5 * time.Second
+
+// Client wraps a udnssdk.Client with the provider-level settings
+// shared by every resource
+type Client struct {
+	*udnssdk.Client
+
+	ReadOnly bool
+	// CheckZoneSerial requires a zone's serial to be unchanged, other
+	// than by this client, between plan and apply
+	CheckZoneSerial bool
+	// DeniedRecordTypes holds the upper-cased RRTypes that plans may
+	// not create
+	DeniedRecordTypes map[string]bool
+	// OwnerNamePatterns holds the compiled owner_name_policy patterns
+	// by zone, lower-cased and without the trailing dot
+	OwnerNamePatterns map[string][]*regexp.Regexp
+
+	transport *transport
+
+	mu sync.Mutex
+	// ownSerials holds the zone serials produced by this client's writes
+	ownSerials map[string]map[int]bool
 }
 
 // Client returns a new client for accessing UltraDNS.
-func (c *Config) Client() (*udnssdk.Client, error) {
-	client, err := udnssdk.NewClient(c.Username, c.Password, c.BaseURL)
+func (c *Config) Client() (*Client, error) {
+	baseURL := c.BaseURL
+	if c.Mock {
+		server, err := sharedMockServer(c.Username, c.MockStateFile)
+		if err != nil {
+			return nil, err
+		}
+		baseURL = server.URL + "/"
+		log.Printf("[WARN] UltraDNS Client is using the mock API at %s; nothing is sent to UltraDNS", baseURL)
+	} else if len(c.FallbackBaseURLs) > 0 {
+		var err error
+		baseURL, err = selectBaseURL(append([]string{c.BaseURL}, c.FallbackBaseURLs...), dialEndpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// udnssdk appends the API paths to the base URL as they are
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	client, err := udnssdk.NewClient(c.Username, c.Password, baseURL)
 
 	if err != nil {
 		return nil, fmt.Errorf("Error setting up client: %s", err)
 	}
 
+	// Tokens are kept by refreshingTokenSource alone, so that one the
+	// API refuses can be replaced
+	tokens := &refreshingTokenSource{conf: &oauth2.Config{Endpoint: client.Config.Endpoint}}
+	if c.AccessToken != "" || c.RefreshToken != "" {
+		// A token without an access token is refreshed before first use
+		tokens.token = &oauth2.Token{AccessToken: c.AccessToken, RefreshToken: c.RefreshToken, TokenType: "Bearer"}
+		log.Printf("[INFO] UltraDNS Client authenticating with a token instead of a password")
+	} else {
+		tokens.base = &passwordTokenSource{conf: tokens.conf, username: c.Username, password: c.Password}
+		if c.TokenCacheFile != "" {
+			tokens.base = newFileTokenSource(c.TokenCacheFile, c.Username, client.Config.Endpoint.TokenURL, tokens.base)
+		}
+	}
+	client.HTTPClient = &http.Client{Transport: &oauth2.Transport{Source: tokens}}
+
+	t := &transport{
+		base:          client.HTTPClient.Transport,
+		changeComment: c.ChangeComment,
+		logJSON:       c.LogFormat == "json",
+
+		breakerThreshold: c.MaxConsecutiveFailures,
+
+		tokens: tokens,
+	}
+	if c.AuditFile != "" {
+		t.audit, err = newAuditLog(c.AuditFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	client.HTTPClient.Transport = t
+
+	denied := map[string]bool{}
+	for _, typ := range c.DeniedRecordTypes {
+		denied[strings.ToUpper(strings.TrimSpace(typ))] = true
+	}
+	patterns, err := compileOwnerNamePolicies(c.OwnerNamePolicies)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Printf("[INFO] UltraDNS Client configured for user: %s", c.Username)
 
-	return client, nil
+	return &Client{
+		Client:    client,
+		ReadOnly:  c.ReadOnly,
+		transport: t,
+
+		CheckZoneSerial:   c.CheckZoneSerial,
+		DeniedRecordTypes: denied,
+		OwnerNamePatterns: patterns,
+	}, nil
+}
+
+// compileOwnerNamePolicies compiles the patterns of each zone, anchored
+// so that they must match the whole owner name
+func compileOwnerNamePolicies(policies map[string][]string) (map[string][]*regexp.Regexp, error) {
+	compiled := map[string][]*regexp.Regexp{}
+	for zone, patterns := range policies {
+		key := strings.ToLower(strings.TrimSuffix(zone, "."))
+		for _, p := range patterns {
+			re, err := regexp.Compile("^(?:" + p + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("owner_name_policy for zone %q: invalid pattern %q: %v", zone, p, err)
+			}
+			compiled[key] = append(compiled[key], re)
+		}
+	}
+	return compiled, nil
+}
+
+// selectBaseURL returns the first of baseURLs whose host accepts a
+// connection. The choice is made once, before authenticating, because
+// udnssdk fetches its token from the base URL it was created with.
+func selectBaseURL(baseURLs []string, dial func(addr string) error) (string, error) {
+	errs := []string{}
+	for i, baseURL := range baseURLs {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", baseURL, err))
+			continue
+		}
+		addr := u.Host
+		if u.Port() == "" {
+			port := "443"
+			if u.Scheme == "http" {
+				port = "80"
+			}
+			addr = net.JoinHostPort(u.Hostname(), port)
+		}
+		if err := dial(addr); err != nil {
+			log.Printf("[WARN] UltraDNS API endpoint %s is unreachable: %v", baseURL, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", baseURL, err))
+			continue
+		}
+		if i > 0 {
+			log.Printf("[WARN] UltraDNS Client is failing over to the API endpoint %s", baseURL)
+		}
+		return baseURL, nil
+	}
+	return "", fmt.Errorf("no UltraDNS API endpoint is reachable: %s", strings.Join(errs, "; "))
+}
+
+func dialEndpoint(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, endpointDialTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
 }
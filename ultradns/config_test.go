@@ -0,0 +1,164 @@
+package ultradns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+func TestSelectBaseURL(t *testing.T) {
+	dialed := []string{}
+	up := map[string]bool{"restapi-eu.example.net:443": true, "localhost:8080": true}
+	dial := func(addr string) error {
+		dialed = append(dialed, addr)
+		if !up[addr] {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}
+
+	got, err := selectBaseURL([]string{"https://restapi.example.net/", "https://restapi-eu.example.net/", "http://localhost:8080/"}, dial)
+	if err != nil || got != "https://restapi-eu.example.net/" {
+		t.Errorf("selectBaseURL = %q, %v, want the first reachable endpoint", got, err)
+	}
+	if strings.Join(dialed, " ") != "restapi.example.net:443 restapi-eu.example.net:443" {
+		t.Errorf("dialed %v", dialed)
+	}
+
+	_, err = selectBaseURL([]string{"https://restapi.example.net/", "http://other.example.net/"}, dial)
+	if err == nil || !strings.Contains(err.Error(), "other.example.net") {
+		t.Errorf("no endpoint reachable: got %v", err)
+	}
+}
+
+func TestConfigClient_baseURL(t *testing.T) {
+	for _, baseURL := range []string{"https://test-restapi.ultradns.com", "https://test-restapi.ultradns.com/"} {
+		client, err := (&Config{Username: "user", Password: "pass", BaseURL: baseURL}).Client()
+		if err != nil {
+			t.Fatalf("Client(%q): %v", baseURL, err)
+		}
+		req, err := client.NewRequest("GET", "zones", nil)
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		if got := req.URL.String(); got != "https://test-restapi.ultradns.com/v1/zones" {
+			t.Errorf("base URL %q: request URL = %s", baseURL, got)
+		}
+	}
+}
+
+func TestConfigClient_tokens(t *testing.T) {
+	grants := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/authorization/token") {
+			r.ParseForm()
+			grants = append(grants, r.Form.Get("grant_type")+" "+r.Form.Get("refresh_token"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token": "refreshed", "refresh_token": "r2", "token_type": "Bearer", "expires_in": 3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer refreshed" && r.Header.Get("Authorization") != "Bearer a1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"properties": {"name": "example.com."}}`)
+	}))
+	defer ts.Close()
+
+	for _, c := range []struct {
+		config Config
+		grants []string
+	}{
+		{Config{AccessToken: "a1", BaseURL: ts.URL}, []string{}},
+		{Config{RefreshToken: "r1", BaseURL: ts.URL}, []string{"refresh_token r1"}},
+	} {
+		grants = []string{}
+		client, err := c.config.Client()
+		if err != nil {
+			t.Fatalf("Client: %v", err)
+		}
+		if _, err := findZone(client, "example.com"); err != nil {
+			t.Errorf("%+v: findZone: %v", c.config, err)
+		}
+		if fmt.Sprint(grants) != fmt.Sprint(c.grants) {
+			t.Errorf("%+v: token requests = %v, want %v", c.config, grants, c.grants)
+		}
+	}
+}
+
+func TestConfigClient_refreshOnUnauthorized(t *testing.T) {
+	grants := []string{}
+	bodies := []string{}
+	// valid holds the access tokens the API accepts
+	valid := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/authorization/token") {
+			r.ParseForm()
+			grants = append(grants, r.Form.Get("grant_type")+" "+r.Form.Get("refresh_token"))
+			token := fmt.Sprintf("t%d", len(grants))
+			if r.Form.Get("grant_type") == "refresh_token" {
+				valid[token] = true
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token": %q, "refresh_token": "r%d", "token_type": "Bearer", "expires_in": 3600}`, token, len(grants))
+			return
+		}
+		if !valid[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"errorCode": 60001, "errorMessage": "invalid_grant:token not found, expired or invalid"}]`)
+			return
+		}
+		if r.Method == http.MethodPost {
+			b, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		fmt.Fprint(w, `{"properties": {"name": "example.com."}}`)
+	}))
+	defer ts.Close()
+
+	// The token of the password grant is refused, so it is refreshed
+	client, err := (&Config{Username: "user", Password: "pass", BaseURL: ts.URL}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	if _, err := findZone(client, "example.com"); err != nil {
+		t.Fatalf("findZone: %v", err)
+	}
+	if want := "[password  refresh_token r1]"; fmt.Sprint(grants) != want {
+		t.Errorf("token requests = %v, want %v", grants, want)
+	}
+
+	// A modifying call is sent again with its body
+	valid = map[string]bool{}
+	rr := udnssdk.RRSet{OwnerName: "www", RRType: "A", TTL: 300, RData: []string{"192.0.2.1"}}
+	if _, err := client.RRSets.Create(udnssdk.RRSetKey{Zone: "example.com", Name: "www", Type: "A"}, rr); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "192.0.2.1") {
+		t.Errorf("bodies = %q", bodies)
+	}
+	if want := "[password  refresh_token r1 refresh_token r2]"; fmt.Sprint(grants) != want {
+		t.Errorf("token requests = %v, want %v", grants, want)
+	}
+
+	// A configured access token alone cannot be replaced
+	client, err = (&Config{AccessToken: "a1", BaseURL: ts.URL}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	grants = []string{}
+	if _, err := findZone(client, "example.com"); err == nil {
+		t.Error("findZone with a refused access token: expected an error")
+	}
+	if len(grants) != 0 {
+		t.Errorf("token requests = %v, want none", grants)
+	}
+}
@@ -0,0 +1,93 @@
+package ultradns
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// credentials are the settings of a profile in a credentials file
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	BaseURL  string `json:"base_url"`
+}
+
+// readCredentialsFile returns the named profile of a credentials file.
+// The file is either JSON, an object of profiles, or INI, a section per
+// profile:
+//
+//	[default]
+//	username = terraform
+//	password = secret
+//	base_url = https://test-restapi.ultradns.com/
+func readCredentialsFile(path, profile string) (credentials, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return credentials{}, err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return credentials{}, fmt.Errorf("reading the credentials file failed: %v", err)
+	}
+
+	var profiles map[string]credentials
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		err = json.Unmarshal(b, &profiles)
+	} else {
+		profiles, err = parseCredentialsINI(b)
+	}
+	if err != nil {
+		return credentials{}, fmt.Errorf("parsing the credentials file %s failed: %v", path, err)
+	}
+	c, ok := profiles[profile]
+	if !ok {
+		return credentials{}, fmt.Errorf("the credentials file %s has no profile %q", path, profile)
+	}
+	return c, nil
+}
+
+// parseCredentialsINI parses "[profile]" sections of "key = value"
+// lines. Lines starting with "#" or ";" are comments.
+func parseCredentialsINI(b []byte) (map[string]credentials, error) {
+	profiles := map[string]credentials{}
+	profile := ""
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		switch {
+		case text == "" || text[0] == '#' || text[0] == ';':
+			continue
+		case text[0] == '[' && text[len(text)-1] == ']':
+			profile = strings.TrimSpace(text[1 : len(text)-1])
+			profiles[profile] = profiles[profile]
+			continue
+		}
+		kv := strings.SplitN(text, "=", 2)
+		if len(kv) != 2 || profile == "" {
+			return nil, fmt.Errorf("line %d: want key = value in a [profile] section", line)
+		}
+		c := profiles[profile]
+		value := strings.TrimSpace(kv[1])
+		switch key := strings.TrimSpace(kv[0]); key {
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		case "base_url":
+			c.BaseURL = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", line, key)
+		}
+		profiles[profile] = c
+	}
+	return profiles, s.Err()
+}
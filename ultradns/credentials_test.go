@@ -0,0 +1,95 @@
+package ultradns
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestReadCredentialsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ultradns-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	ini := write("credentials", `
+# UltraDNS accounts
+[default]
+username = prod-user
+password = prod=pass
+
+[uat]
+username = uat-user
+password = uat-pass
+base_url = https://test-restapi.ultradns.com/
+`)
+	json := write("credentials.json", `{
+  "default": {"username": "prod-user", "password": "prod=pass"},
+  "uat": {"username": "uat-user", "password": "uat-pass", "base_url": "https://test-restapi.ultradns.com/"}
+}`)
+	for _, path := range []string{ini, json} {
+		c, err := readCredentialsFile(path, "default")
+		if err != nil || c != (credentials{Username: "prod-user", Password: "prod=pass"}) {
+			t.Errorf("%s default: got %+v, %v", path, c, err)
+		}
+		c, err = readCredentialsFile(path, "uat")
+		if err != nil || c != (credentials{Username: "uat-user", Password: "uat-pass", BaseURL: "https://test-restapi.ultradns.com/"}) {
+			t.Errorf("%s uat: got %+v, %v", path, c, err)
+		}
+		if _, err := readCredentialsFile(path, "missing"); err == nil {
+			t.Errorf("%s: expected a missing profile to fail", path)
+		}
+	}
+
+	for _, content := range []string{"username = user\n", "[default]\nuser = x\n", "[default]\nusername\n"} {
+		if _, err := readCredentialsFile(write("bad", content), "default"); err == nil {
+			t.Errorf("%q: expected an error", content)
+		}
+	}
+	if _, err := readCredentialsFile(filepath.Join(dir, "none"), "default"); err == nil {
+		t.Errorf("expected a missing file to fail")
+	}
+
+	// The provider block and environment override the profile
+	for _, name := range []string{"ULTRADNS_USERNAME", "ULTRADNS_PASSWORD", "ULTRADNS_BASE_URL", "ULTRADNS_BASEURL", "ULTRADNS_PROFILE", "ULTRADNS_CREDENTIALS_FILE"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	configure := func(cfg map[string]interface{}) (*Client, error) {
+		p := Provider().(*schema.Provider)
+		if err := p.Configure(terraform.NewResourceConfigRaw(cfg)); err != nil {
+			return nil, err
+		}
+		return p.Meta().(*Client), nil
+	}
+	client, err := configure(map[string]interface{}{"credentials_file": ini, "profile": "uat"})
+	if err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if client.Config.Username != "uat-user" || client.BaseURL.String() != "https://test-restapi.ultradns.com/" {
+		t.Errorf("uat profile: username %q, base URL %s", client.Config.Username, client.BaseURL)
+	}
+	os.Setenv("ULTRADNS_USERNAME", "env-user")
+	client, err = configure(map[string]interface{}{"credentials_file": ini})
+	if err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if client.Config.Username != "env-user" || client.Config.Password != "prod=pass" || client.BaseURL.String() != "https://restapi.ultradns.com/" {
+		t.Errorf("default profile: username %q, password %q, base URL %s", client.Config.Username, client.Config.Password, client.BaseURL)
+	}
+	if _, err := configure(map[string]interface{}{}); err == nil {
+		t.Errorf("expected a missing password to fail")
+	}
+}
@@ -0,0 +1,124 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/terra-farm/udnssdk"
+)
+
+func dataSourceUltradnsAccount() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsAccountRead,
+
+		Schema: map[string]*schema.Schema{
+			// Optional
+			"account_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			// Computed
+			"account_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"account_holder_user_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"owner_user_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"number_of_users": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"number_of_groups": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"zone_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"record_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"largest_zone_record_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsAccountRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	name := d.Get("account_name").(string)
+	log.Printf("[INFO] ultradns_account read: account_name: %q", name)
+
+	a, err := findAccount(client, name)
+	if err != nil {
+		return err
+	}
+
+	// The API exposes no account quotas, so report usage by walking the
+	// zones index
+	zones, records, largest := 0, 0, 0
+	err = selectZones(client, "", func(z zoneDTO) error {
+		if z.Properties.AccountName != a.AccountName {
+			return nil
+		}
+		zones++
+		records += z.Properties.ResourceRecordCount
+		if z.Properties.ResourceRecordCount > largest {
+			largest = z.Properties.ResourceRecordCount
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("zones list failed: %v", err)
+	}
+
+	d.SetId(a.AccountName)
+	d.Set("account_name", a.AccountName)
+	d.Set("account_type", a.AccountType)
+	d.Set("account_holder_user_name", a.AccountHolderUserName)
+	d.Set("owner_user_name", a.OwnerUserName)
+	d.Set("number_of_users", a.NumberOfUsers)
+	d.Set("number_of_groups", a.NumberOfGroups)
+	d.Set("zone_count", zones)
+	d.Set("record_count", records)
+	d.Set("largest_zone_record_count", largest)
+	return nil
+}
+
+// findAccount returns the account named name, or the only account
+// visible to the credentials if name is empty
+func findAccount(client *Client, name string) (udnssdk.Account, error) {
+	accts, _, err := client.Accounts.Select()
+	if err != nil {
+		return udnssdk.Account{}, fmt.Errorf("accounts list failed: %v", err)
+	}
+
+	names := []string{}
+	for _, a := range accts {
+		if name != "" && a.AccountName == name {
+			return a, nil
+		}
+		names = append(names, a.AccountName)
+	}
+	if name == "" && len(accts) == 1 {
+		return accts[0], nil
+	}
+	if name == "" {
+		return udnssdk.Account{}, fmt.Errorf("account_name is required when the credentials can see %d accounts: %s", len(accts), strings.Join(names, ", "))
+	}
+	return udnssdk.Account{}, fmt.Errorf("no account named %q, expected one of: %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,28 @@
+package ultradns
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestAccDataSourceUltradnsAccount(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testCfgDataSourceAccount,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.ultradns_account.it", "account_name"),
+					resource.TestCheckResourceAttrSet("data.ultradns_account.it", "zone_count"),
+					resource.TestCheckResourceAttrSet("data.ultradns_account.it", "record_count"),
+				),
+			},
+		},
+	})
+}
+
+const testCfgDataSourceAccount = `
+data "ultradns_account" "it" {}
+`
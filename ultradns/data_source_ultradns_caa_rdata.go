@@ -0,0 +1,133 @@
+package ultradns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceUltradnsCAARdata() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsCAARdataRead,
+
+		Schema: map[string]*schema.Schema{
+			// Optional
+			"issuers": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateCAAValue,
+				},
+			},
+			"wildcard_issuers": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateCAAValue,
+				},
+			},
+			"forbid_wildcard": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"iodef": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateCAAValue,
+				},
+			},
+			"critical": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			// Computed
+			"rdata": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsCAARdataRead(d *schema.ResourceData, meta interface{}) error {
+	wildcard := stringsFromList(d.Get("wildcard_issuers").([]interface{}))
+	if d.Get("forbid_wildcard").(bool) {
+		if len(wildcard) > 0 {
+			return fmt.Errorf("forbid_wildcard conflicts with wildcard_issuers")
+		}
+		wildcard = []string{""}
+	}
+	rdata := caaRdata(
+		stringsFromList(d.Get("issuers").([]interface{})),
+		wildcard,
+		stringsFromList(d.Get("iodef").([]interface{})),
+		d.Get("critical").(bool),
+	)
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(rdata, "\n"))))
+	err := d.Set("rdata", rdata)
+	if err != nil {
+		return fmt.Errorf("rdata set failed: %v", err)
+	}
+	return nil
+}
+
+// caaRdata renders the RFC 8659 CAA rdata for a policy. No issuers
+// forbids issuance; an empty wildcard issuer forbids wildcard issuance,
+// and no wildcard issuers leaves it to issuers. Contacts that are bare
+// email addresses get a mailto: scheme.
+func caaRdata(issuers, wildcardIssuers, iodef []string, critical bool) []string {
+	flags := 0
+	if critical {
+		flags = 128
+	}
+	if len(issuers) == 0 {
+		issuers = []string{""}
+	}
+
+	rdata := []string{}
+	for _, i := range issuers {
+		rdata = append(rdata, caaProperty(flags, "issue", caaIssuerValue(i)))
+	}
+	for _, i := range wildcardIssuers {
+		rdata = append(rdata, caaProperty(flags, "issuewild", caaIssuerValue(i)))
+	}
+	for _, c := range iodef {
+		if !strings.Contains(c, ":") && strings.Contains(c, "@") {
+			c = "mailto:" + c
+		}
+		rdata = append(rdata, caaProperty(0, "iodef", c))
+	}
+	return rdata
+}
+
+// caaIssuerValue renders an issuer, where "" means no CA may issue
+func caaIssuerValue(issuer string) string {
+	if strings.TrimSpace(issuer) == "" {
+		return ";"
+	}
+	return strings.TrimSpace(issuer)
+}
+
+func caaProperty(flags int, tag, value string) string {
+	return fmt.Sprintf("%d %s \"%s\"", flags, tag, value)
+}
+
+// validateCAAValue is a SchemaValidateFunc for the values of CAA
+// properties, which are quoted and so can't contain quotes themselves
+func validateCAAValue(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if strings.ContainsAny(value, "\"\\") {
+		errors = append(errors, fmt.Errorf("%s: %q must not contain quotes or backslashes", k, value))
+	}
+	return
+}
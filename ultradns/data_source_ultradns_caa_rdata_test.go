@@ -0,0 +1,48 @@
+package ultradns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCAARdata(t *testing.T) {
+	cases := []struct {
+		issuers, wildcard, iodef []string
+		critical                 bool
+		want                     []string
+	}{
+		{
+			nil, nil, nil, false,
+			[]string{`0 issue ";"`},
+		},
+		{
+			[]string{"letsencrypt.org", " digicert.com; cansignhttpexchanges=yes"}, []string{""}, []string{"security@example.com", "https://example.com/caa"}, false,
+			[]string{
+				`0 issue "letsencrypt.org"`,
+				`0 issue "digicert.com; cansignhttpexchanges=yes"`,
+				`0 issuewild ";"`,
+				`0 iodef "mailto:security@example.com"`,
+				`0 iodef "https://example.com/caa"`,
+			},
+		},
+		{
+			[]string{"letsencrypt.org"}, []string{"sectigo.com"}, nil, true,
+			[]string{`128 issue "letsencrypt.org"`, `128 issuewild "sectigo.com"`},
+		},
+	}
+
+	for _, c := range cases {
+		if got := caaRdata(c.issuers, c.wildcard, c.iodef, c.critical); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("caaRdata(%q, %q, %q, %v) = %q, want %q", c.issuers, c.wildcard, c.iodef, c.critical, got, c.want)
+		}
+	}
+}
+
+func TestValidateCAAValue(t *testing.T) {
+	if _, es := validateCAAValue("letsencrypt.org", "issuers.0"); len(es) != 0 {
+		t.Errorf("valid issuer: %v", es)
+	}
+	if _, es := validateCAAValue(`"letsencrypt.org"`, "issuers.0"); len(es) != 1 {
+		t.Errorf("quoted issuer: %v", es)
+	}
+}
@@ -0,0 +1,109 @@
+package ultradns
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// txtStringLimit is the longest character-string a TXT record can hold
+const txtStringLimit = 255
+
+func dataSourceUltradnsDKIMTXT() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsDKIMTXTRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"public_key": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Computed
+			"key_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"value": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"chunks": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsDKIMTXTRead(d *schema.ResourceData, meta interface{}) error {
+	keyType, value, err := dkimTXT(d.Get("public_key").(string))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(value)))
+	d.Set("key_type", keyType)
+	d.Set("value", value)
+	err = d.Set("chunks", chunkString(value, txtStringLimit))
+	if err != nil {
+		return fmt.Errorf("chunks set failed: %v", err)
+	}
+	return nil
+}
+
+// dkimTXT renders the DKIM key record for a PEM public key. RSA keys
+// are published as their SubjectPublicKeyInfo (RFC 6376) and Ed25519
+// keys as the raw key (RFC 8463).
+func dkimTXT(keyPEM string) (keyType, value string, err error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return "", "", fmt.Errorf("public_key must be PEM encoded")
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return "", "", fmt.Errorf("public_key is a %q PEM block, not a public key", block.Type)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("public_key parse failed: %v", err)
+	}
+
+	var p []byte
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		keyType = "rsa"
+		p, err = x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			return "", "", err
+		}
+	case ed25519.PublicKey:
+		keyType = "ed25519"
+		p = k
+	default:
+		return "", "", fmt.Errorf("public_key is a %T; DKIM keys must be RSA or Ed25519", key)
+	}
+	return keyType, fmt.Sprintf("v=DKIM1; k=%s; p=%s", keyType, base64.StdEncoding.EncodeToString(p)), nil
+}
+
+// chunkString splits s into consecutive pieces of at most n bytes
+func chunkString(s string, n int) []string {
+	chunks := []string{}
+	for len(s) > n {
+		chunks = append(chunks, s[:n])
+		s = s[n:]
+	}
+	return append(chunks, s)
+}
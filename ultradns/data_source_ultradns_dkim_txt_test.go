@@ -0,0 +1,54 @@
+package ultradns
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestDKIMTXT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	spki, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	pkix := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki}))
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}))
+	want := "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(spki)
+	for _, keyPEM := range []string{pkix, pkcs1} {
+		keyType, value, err := dkimTXT(keyPEM)
+		if err != nil || keyType != "rsa" || value != want {
+			t.Errorf("RSA: got %q, %q, %v", keyType, value, err)
+		}
+	}
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519 key: %v", err)
+	}
+	spki, _ = x509.MarshalPKIXPublicKey(edKey)
+	keyType, value, err := dkimTXT(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki})))
+	if err != nil || keyType != "ed25519" || value != "v=DKIM1; k=ed25519; p="+base64.StdEncoding.EncodeToString(edKey) {
+		t.Errorf("Ed25519: got %q, %q, %v", keyType, value, err)
+	}
+
+	if _, _, err := dkimTXT("-----BEGIN CERTIFICATE-----\nMA==\n-----END CERTIFICATE-----\n"); err == nil {
+		t.Error("certificate: expected an error")
+	}
+}
+
+func TestChunkString(t *testing.T) {
+	s := strings.Repeat("a", 600)
+	chunks := chunkString(s, 255)
+	if len(chunks) != 3 || len(chunks[0]) != 255 || len(chunks[2]) != 90 || strings.Join(chunks, "") != s {
+		t.Errorf("chunkString: got %d chunks", len(chunks))
+	}
+	if chunks := chunkString("short", 255); len(chunks) != 1 || chunks[0] != "short" {
+		t.Errorf("chunkString(short) = %q", chunks)
+	}
+}
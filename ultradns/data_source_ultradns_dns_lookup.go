@@ -0,0 +1,180 @@
+package ultradns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
+)
+
+// dnsLookupTimeout bounds all the queries of a single lookup
+const dnsLookupTimeout = 10 * time.Second
+
+// dnsLookupTypes are the RRTypes ultradns_dns_lookup can query
+var dnsLookupTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+func dataSourceUltradnsDNSLookup() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsDNSLookupRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(dnsLookupTypes, false),
+			},
+			// Optional
+			"nameserver": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			// Computed
+			"hostname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"answers": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsDNSLookupRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	zone := d.Get("zone").(string)
+	hostname := fqdnOwner(d.Get("name").(string), zone)
+	typ := d.Get("type").(string)
+
+	ns := d.Get("nameserver").(string)
+	if ns == "" {
+		var err error
+		ns, err = findApexNameserver(client, zone)
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("[INFO] ultradns_dns_lookup read: %s %s @%s", hostname, typ, ns)
+
+	answers, err := lookupAuthoritative(nameserverAddress(ns), hostname, typ)
+	if err != nil {
+		return fmt.Errorf("lookup of %s %s at %s failed: %v", hostname, typ, ns, err)
+	}
+	sort.Strings(answers)
+
+	d.SetId(fmt.Sprintf("%s:%s:%s", hostname, typ, ns))
+	d.Set("hostname", hostname)
+	d.Set("nameserver", ns)
+	err = d.Set("answers", answers)
+	if err != nil {
+		return fmt.Errorf("answers set failed: %v", err)
+	}
+	return nil
+}
+
+// findApexNameserver returns the first, in sorted order, of the
+// nameservers of zone's apex NS set, which are UltraDNS's authoritative
+// servers for the zone
+func findApexNameserver(client *Client, zone string) (string, error) {
+	rrsets, err := client.RRSets.Select(udnssdk.RRSetKey{Zone: zone, Type: "NS", Name: fqdnOwner("", zone)})
+	if err != nil {
+		return "", fmt.Errorf("apex NS lookup failed: %v", err)
+	}
+	if len(rrsets) == 0 || len(rrsets[0].RData) == 0 {
+		return "", fmt.Errorf("zone %q has no apex NS records", zone)
+	}
+	ns := append([]string{}, rrsets[0].RData...)
+	sort.Strings(ns)
+	return ns[0], nil
+}
+
+// nameserverAddress returns the host:port to send queries for ns to,
+// which may be a name or address with or without a port
+func nameserverAddress(ns string) string {
+	if _, _, err := net.SplitHostPort(ns); err == nil {
+		return ns
+	}
+	return net.JoinHostPort(strings.Trim(ns, "[]"), "53")
+}
+
+// lookupAuthoritative queries the server at addr directly, without
+// recursion, for the typ records of hostname, and renders the answers
+// like rdata
+func lookupAuthoritative(addr, hostname, typ string) ([]string, error) {
+	r := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	answers := []string{}
+	switch typ {
+	case "A", "AAAA":
+		ips, err := r.LookupIPAddr(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if (ip.IP.To4() != nil) == (typ == "A") {
+				answers = append(answers, ip.IP.String())
+			}
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(cname, hostname) {
+			answers = append(answers, cname)
+		}
+	case "MX":
+		mxs, err := r.LookupMX(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case "NS":
+		nss, err := r.LookupNS(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+	return answers, nil
+}
@@ -0,0 +1,22 @@
+package ultradns
+
+import (
+	"testing"
+)
+
+func TestNameserverAddress(t *testing.T) {
+	cases := map[string]string{
+		"pdns1.ultradns.net.":   "pdns1.ultradns.net.:53",
+		"192.0.2.53":            "192.0.2.53:53",
+		"192.0.2.53:5353":       "192.0.2.53:5353",
+		"2001:db8::53":          "[2001:db8::53]:53",
+		"[2001:db8::53]:5353":   "[2001:db8::53]:5353",
+		"pdns1.ultradns.net:53": "pdns1.ultradns.net:53",
+	}
+
+	for ns, want := range cases {
+		if got := nameserverAddress(ns); got != want {
+			t.Errorf("nameserverAddress(%q) = %q, want %q", ns, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ultradns
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceUltradnsFQDN() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsFQDNRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Optional
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			// Computed
+			"fqdn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"relative_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsFQDNRead(d *schema.ResourceData, meta interface{}) error {
+	fqdn, relative := joinFQDN(d.Get("name").(string), d.Get("zone").(string))
+
+	d.SetId(fqdn)
+	d.Set("fqdn", fqdn)
+	d.Set("relative_name", relative)
+	return nil
+}
+
+// joinFQDN returns the lower-case absolute form of name in zone, and its
+// form relative to zone, "@" at the apex. Names outside zone are
+// relative to nothing, so their relative form is absolute too.
+func joinFQDN(name, zone string) (fqdn, relative string) {
+	zone = strings.ToLower(strings.TrimSuffix(zone, ".") + ".")
+	fqdn = strings.ToLower(fqdnOwner(name, zone))
+	switch {
+	case fqdn == zone:
+		relative = "@"
+	case strings.HasSuffix(fqdn, "."+zone):
+		relative = strings.TrimSuffix(fqdn, "."+zone)
+	default:
+		relative = fqdn
+	}
+	return fqdn, relative
+}
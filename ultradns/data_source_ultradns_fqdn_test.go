@@ -0,0 +1,26 @@
+package ultradns
+
+import (
+	"testing"
+)
+
+func TestJoinFQDN(t *testing.T) {
+	cases := []struct {
+		name, zone, fqdn, relative string
+	}{
+		{"www", "example.com", "www.example.com.", "www"},
+		{"WWW", "Example.COM.", "www.example.com.", "www"},
+		{"", "example.com", "example.com.", "@"},
+		{"@", "example.com.", "example.com.", "@"},
+		{"example.com.", "example.com", "example.com.", "@"},
+		{"a.b.example.com.", "example.com", "a.b.example.com.", "a.b"},
+		{"www.example.net.", "example.com", "www.example.net.", "www.example.net."},
+	}
+
+	for _, c := range cases {
+		fqdn, relative := joinFQDN(c.name, c.zone)
+		if fqdn != c.fqdn || relative != c.relative {
+			t.Errorf("joinFQDN(%q, %q) = %q, %q, want %q, %q", c.name, c.zone, fqdn, relative, c.fqdn, c.relative)
+		}
+	}
+}
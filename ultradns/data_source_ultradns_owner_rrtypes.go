@@ -0,0 +1,74 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/terra-farm/udnssdk"
+)
+
+func dataSourceUltradnsOwnerRRTypes() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsOwnerRRTypesRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Computed
+			"hostname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"types": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Set:      schema.HashString,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsOwnerRRTypesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	zone := d.Get("zone").(string)
+	name := d.Get("name").(string)
+	hostname := fqdnOwner(name, zone)
+
+	// Filter on the absolute name, as "@" or a relative name given with a
+	// trailing dot would match no owner
+	q := rRSetQuery{
+		Zone:  zone,
+		Owner: strings.TrimSuffix(hostname, "."),
+	}
+	log.Printf("[INFO] ultradns_owner_rrtypes read: %#v", q)
+	// The owner filter matches substrings, so keep exact matches only
+	types := []string{}
+	err := eachRRSet(client, q, func(r udnssdk.RRSet) error {
+		if strings.EqualFold(fqdnOwner(r.OwnerName, zone), hostname) {
+			types = append(types, rrTypeName(r.RRType))
+		}
+		return nil
+	})
+	if err != nil && !isRRSetNotFound(err) {
+		return fmt.Errorf("rrsets list failed: %v", err)
+	}
+
+	d.SetId(hostname)
+	d.Set("hostname", hostname)
+	err = d.Set("types", makeSetFromStrings(types))
+	if err != nil {
+		return fmt.Errorf("types set failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package ultradns
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestAccDataSourceUltradnsOwnerRRTypes(t *testing.T) {
+	domain := "ultradns.phinze.com"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccRecordCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCfgDataSourceOwnerRRTypes, domain, domain),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ultradns_owner_rrtypes.it", "hostname", "test-owner-rrtypes.ultradns.phinze.com."),
+					resource.TestCheckResourceAttr("data.ultradns_owner_rrtypes.it", "types.#", "2"),
+					// schema.HashString("A") -> 3554254475
+					resource.TestCheckResourceAttr("data.ultradns_owner_rrtypes.it", "types.3554254475", "A"),
+					// schema.HashString("TXT") -> 2324700623
+					resource.TestCheckResourceAttr("data.ultradns_owner_rrtypes.it", "types.2324700623", "TXT"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceUltradnsOwnerRRTypesRead_mock(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	www := rRSetResource{OwnerName: "www", RRType: "A", Zone: "example.com", TTL: 300, RData: []string{"192.0.2.1"}}
+	if err := createRRSet(client, www, false); err != nil {
+		t.Fatalf("createRRSet: %v", err)
+	}
+
+	for name, want := range map[string][]string{
+		"@":            {"NS", "SOA"},
+		"example.com.": {"NS", "SOA"},
+		"www":          {"A"},
+		"missing":      {},
+	} {
+		d := schema.TestResourceDataRaw(t, dataSourceUltradnsOwnerRRTypes().Schema, map[string]interface{}{
+			"zone": "example.com", "name": name,
+		})
+		if err := dataSourceUltradnsOwnerRRTypesRead(d, client); err != nil {
+			t.Fatalf("%q: Read: %v", name, err)
+		}
+		got := stringsFromList(d.Get("types").(*schema.Set).List())
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: types = %q, want %q", name, got, want)
+		}
+	}
+}
+
+const testCfgDataSourceOwnerRRTypes = `
+resource "ultradns_record" "a" {
+  zone  = "%s"
+  name  = "test-owner-rrtypes"
+  rdata = ["10.5.0.1"]
+  type  = "A"
+}
+
+resource "ultradns_record" "txt" {
+  zone  = "${ultradns_record.a.zone}"
+  name  = "test-owner-rrtypes"
+  rdata = ["owner rrtypes"]
+  type  = "TXT"
+}
+
+data "ultradns_owner_rrtypes" "it" {
+  zone = "%s"
+  name = "test-owner-rrtypes"
+
+  depends_on = ["ultradns_record.a", "ultradns_record.txt"]
+}
+`
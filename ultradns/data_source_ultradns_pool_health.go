@@ -0,0 +1,188 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/terra-farm/udnssdk"
+)
+
+// Pool health states reported by ultradns_pool_health
+const (
+	poolHealthy  = "healthy"
+	poolDegraded = "degraded"
+	poolFailed   = "failed"
+)
+
+func dataSourceUltradnsPoolHealth() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsPoolHealthRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Computed
+			"healthy_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"degraded_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"failed_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"pools": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"hostname": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pool_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"health": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"rdata_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"available_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"backup_serving": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsPoolHealthRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	zone := d.Get("zone").(string)
+	log.Printf("[INFO] ultradns_pool_health read: zone: %q", zone)
+	counts := map[string]int{}
+	pools := []map[string]interface{}{}
+	var poolErr error
+	err := eachRRSet(client, rRSetQuery{Zone: zone, Kind: "POOLS"}, func(r udnssdk.RRSet) error {
+		pool, err := mapFromProbedPool(r, zone)
+		if err != nil || pool == nil {
+			poolErr = err
+			return err
+		}
+		counts[pool["health"].(string)]++
+		pools = append(pools, pool)
+		return nil
+	})
+	if poolErr != nil {
+		return poolErr
+	}
+	if err != nil && !isRRSetNotFound(err) {
+		return fmt.Errorf("rrsets list failed: %v", err)
+	}
+
+	d.SetId(zone)
+	d.Set("healthy_count", counts[poolHealthy])
+	d.Set("degraded_count", counts[poolDegraded])
+	d.Set("failed_count", counts[poolFailed])
+	err = d.Set("pools", pools)
+	if err != nil {
+		return fmt.Errorf("pools set failed: %v", err)
+	}
+	return nil
+}
+
+// mapFromProbedPool encodes the health of a Traffic Controller or
+// SiteBacker pool into a map[string]interface{} in the appropriate
+// structure for the schema. Other RRSets, which are not probed, give nil.
+func mapFromProbedPool(r udnssdk.RRSet, zone string) (map[string]interface{}, error) {
+	if r.Profile == nil {
+		return nil, nil
+	}
+
+	var infos []udnssdk.SBRDataInfo
+	var backups []udnssdk.BackupRecord
+	poolType, status := "", ""
+	// Read @context directly, as RawProfile.Context panics without it
+	c, _ := r.Profile["@context"].(string)
+	switch udnssdk.ProfileSchema(c) {
+	case udnssdk.TCPoolSchema:
+		p, err := r.Profile.TCPoolProfile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: TCPoolProfile conversion failed: %v", r.OwnerName, err)
+		}
+		poolType, status, infos = "TC", p.Status, p.RDataInfo
+		if p.BackupRecord != nil {
+			backups = []udnssdk.BackupRecord{*p.BackupRecord}
+		}
+	case udnssdk.SBPoolSchema:
+		p, err := r.Profile.SBPoolProfile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: SBPoolProfile conversion failed: %v", r.OwnerName, err)
+		}
+		poolType, infos, backups = "SB", p.RDataInfo, p.BackupRecords
+	default:
+		return nil, nil
+	}
+
+	available := 0
+	for _, i := range infos {
+		if i.AvailableToServe {
+			available++
+		}
+	}
+	backupServing := false
+	for _, b := range backups {
+		backupServing = backupServing || b.AvailableToServe
+	}
+
+	return map[string]interface{}{
+		"hostname":        fqdnOwner(r.OwnerName, zone),
+		"type":            rrTypeName(r.RRType),
+		"pool_type":       poolType,
+		"health":          poolHealth(len(infos), available, backupServing),
+		"status":          status,
+		"rdata_count":     len(infos),
+		"available_count": available,
+		"backup_serving":  backupServing,
+	}, nil
+}
+
+// poolHealth summarizes a pool with total records, of which available
+// are being served: healthy when all are, degraded when only some are or
+// a backup is serving in their place, and failed otherwise
+func poolHealth(total, available int, backupServing bool) string {
+	switch {
+	case available == total && total > 0:
+		return poolHealthy
+	case available > 0 || backupServing:
+		return poolDegraded
+	}
+	return poolFailed
+}
@@ -0,0 +1,58 @@
+package ultradns
+
+import (
+	"testing"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+func TestPoolHealth(t *testing.T) {
+	cases := []struct {
+		total, available int
+		backupServing    bool
+		want             string
+	}{
+		{2, 2, false, poolHealthy},
+		{2, 1, false, poolDegraded},
+		{2, 0, true, poolDegraded},
+		{2, 0, false, poolFailed},
+		{0, 0, false, poolFailed},
+	}
+
+	for _, c := range cases {
+		if got := poolHealth(c.total, c.available, c.backupServing); got != c.want {
+			t.Errorf("poolHealth(%d, %d, %v) = %q, want %q", c.total, c.available, c.backupServing, got, c.want)
+		}
+	}
+}
+
+func TestMapFromProbedPool(t *testing.T) {
+	tc := udnssdk.RRSet{
+		OwnerName: "pool.example.com.",
+		RRType:    "A (1)",
+		Profile: udnssdk.RawProfile{
+			"@context": string(udnssdk.TCPoolSchema),
+			"status":   "WARNING",
+			"rdataInfo": []interface{}{
+				map[string]interface{}{"availableToServe": true},
+				map[string]interface{}{"availableToServe": false},
+			},
+		},
+	}
+	pool, err := mapFromProbedPool(tc, "example.com")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pool["pool_type"] != "TC" || pool["health"] != poolDegraded || pool["status"] != "WARNING" || pool["available_count"] != 1 || pool["type"] != "A" {
+		t.Errorf("TC pool: got %v", pool)
+	}
+
+	rd := udnssdk.RRSet{
+		OwnerName: "rd.example.com.",
+		RRType:    "A (1)",
+		Profile:   udnssdk.RawProfile{"@context": string(udnssdk.RDPoolSchema)},
+	}
+	if pool, err := mapFromProbedPool(rd, "example.com"); pool != nil || err != nil {
+		t.Errorf("RD pool: got %v, %v", pool, err)
+	}
+}
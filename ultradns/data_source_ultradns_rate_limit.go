@@ -0,0 +1,56 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceUltradnsRateLimit() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsRateLimitRead,
+
+		Schema: map[string]*schema.Schema{
+			// Computed
+			"reported": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"limit": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"remaining": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"reset": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsRateLimitRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	rl, ok := client.transport.lastRateLimit()
+	if !ok {
+		// No call this run has reported a quota yet, so make a cheap one
+		_, err := client.Do("GET", "accounts", nil, nil)
+		if err != nil {
+			return fmt.Errorf("accounts read failed: %v", err)
+		}
+		rl, ok = client.transport.lastRateLimit()
+	}
+	log.Printf("[INFO] ultradns_rate_limit read: reported: %v, %+v", ok, rl)
+
+	d.SetId("rate_limit")
+	d.Set("reported", ok)
+	d.Set("limit", rl.Limit)
+	d.Set("remaining", rl.Remaining)
+	d.Set("reset", rl.Reset)
+	return nil
+}
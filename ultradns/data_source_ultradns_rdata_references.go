@@ -0,0 +1,147 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
+)
+
+func dataSourceUltradnsRdataReferences() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsRdataReferencesRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"target": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringDoesNotContainAny(" \t"),
+			},
+			// Computed
+			"records": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"hostname": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ttl": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"pool_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"rdata": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"matches": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsRdataReferencesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	target := d.Get("target").(string)
+
+	q := rRSetQuery{Zone: d.Get("zone").(string)}
+	// IPv6 addresses can be written in many ways, so only names and IPv4
+	// addresses can narrow the search server-side
+	if ip := net.ParseIP(target); ip == nil || ip.To4() != nil {
+		q.Value = strings.TrimSuffix(target, ".")
+	}
+	log.Printf("[INFO] ultradns_rdata_references read: %#v", q)
+	records := []map[string]interface{}{}
+	err := eachRRSet(client, q, func(r udnssdk.RRSet) error {
+		m := mapFromRRSet(r, q.Zone)
+		matches := []string{}
+		for _, rdata := range m["rdata"].([]string) {
+			if rdataReferences(rdata, target) {
+				matches = append(matches, rdata)
+			}
+		}
+		if len(matches) > 0 {
+			m["pool_type"] = poolTypeOf(r)
+			m["matches"] = matches
+			records = append(records, m)
+		}
+		return nil
+	})
+	if err != nil && !isRRSetNotFound(err) {
+		return fmt.Errorf("rrsets list failed: %v", err)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(fmt.Sprintf("%s:%s", q.Zone, target))))
+	err = d.Set("records", records)
+	if err != nil {
+		return fmt.Errorf("records set failed: %v", err)
+	}
+	return nil
+}
+
+// poolTypeOf describes the pool type of r, or returns "" for a plain
+// record
+func poolTypeOf(r udnssdk.RRSet) string {
+	// Read @context directly, as RawProfile.Context panics without it
+	c, _ := r.Profile["@context"].(string)
+	if t := poolProfileTypes[udnssdk.ProfileSchema(c)][0]; t != "" {
+		return t
+	}
+	return c
+}
+
+// rdataReferences reports whether a word of rdata is target: an address
+// equal to it, if target is an IP address, or else a name equal to it
+// regardless of case and the trailing dot. The argument of SPF terms
+// such as "ip4:" and "include:" counts as a word.
+func rdataReferences(rdata, target string) bool {
+	ip := net.ParseIP(target)
+	name := strings.ToLower(strings.TrimSuffix(target, "."))
+	for _, word := range strings.Fields(rdata) {
+		word = strings.Trim(word, `"`)
+		candidates := []string{word}
+		if i := strings.Index(word, ":"); i > 0 && isMnemonic(strings.TrimLeft(word[:i], "+-~?")) {
+			arg := word[i+1:]
+			if j := strings.Index(arg, "/"); j >= 0 {
+				arg = arg[:j]
+			}
+			candidates = append(candidates, arg)
+		}
+		for _, c := range candidates {
+			if ip != nil {
+				if other := net.ParseIP(c); other != nil && other.Equal(ip) {
+					return true
+				}
+			} else if strings.ToLower(strings.TrimSuffix(c, ".")) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
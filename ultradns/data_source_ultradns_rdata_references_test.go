@@ -0,0 +1,78 @@
+package ultradns
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestRdataReferences(t *testing.T) {
+	cases := []struct {
+		rdata, target string
+		want          bool
+	}{
+		{"192.0.2.1", "192.0.2.1", true},
+		{"192.0.2.10", "192.0.2.1", false},
+		{"2001:db8:0:0::1", "2001:db8::1", true},
+		{"www.Example.com.", "www.example.com", true},
+		{"10 mail.example.com.", "mail.example.com.", true},
+		{"10 mail.example.com.", "example.com", false},
+		{"v=spf1 ip4:192.0.2.0/24 -all", "192.0.2.0", true},
+		{`"v=spf1 ~include:_spf.example.net -all"`, "_spf.example.net", true},
+		{"v=spf1 ip6:2001:db8::1 -all", "2001:db8:0::1", true},
+		{"0 issue \"example.net\"", "example.net", true},
+	}
+	for _, c := range cases {
+		if got := rdataReferences(c.rdata, c.target); got != c.want {
+			t.Errorf("rdataReferences(%q, %q) = %v, want %v", c.rdata, c.target, got, c.want)
+		}
+	}
+}
+
+func TestDataSourceUltradnsRdataReferencesRead(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	for _, r := range []rRSetResource{
+		{OwnerName: "www", RRType: "CNAME", RData: []string{"web.example.com."}},
+		{OwnerName: "web", RRType: "A", RData: []string{"192.0.2.1", "192.0.2.2"}},
+		{OwnerName: "old", RRType: "A", RData: []string{"192.0.2.10"}},
+		{OwnerName: "v6", RRType: "AAAA", RData: []string{"2001:db8:0:0::1"}},
+	} {
+		r.Zone, r.TTL = "example.com", 300
+		if err := createRRSet(client, r, false); err != nil {
+			t.Fatalf("createRRSet: %v", err)
+		}
+	}
+
+	read := func(target string) []interface{} {
+		d := schema.TestResourceDataRaw(t, dataSourceUltradnsRdataReferences().Schema, map[string]interface{}{
+			"zone":   "example.com",
+			"target": target,
+		})
+		if err := dataSourceUltradnsRdataReferencesRead(d, client); err != nil {
+			t.Fatalf("read %s: %v", target, err)
+		}
+		return d.Get("records").([]interface{})
+	}
+
+	records := read("192.0.2.1")
+	if len(records) != 1 {
+		t.Fatalf("192.0.2.1: got %v", records)
+	}
+	m := records[0].(map[string]interface{})
+	if m["hostname"] != "web.example.com." || len(m["rdata"].([]interface{})) != 2 || len(m["matches"].([]interface{})) != 1 {
+		t.Errorf("192.0.2.1: got %v", m)
+	}
+
+	if records := read("WEB.example.com"); len(records) != 1 || records[0].(map[string]interface{})["type"] != "CNAME" {
+		t.Errorf("WEB.example.com: got %v", records)
+	}
+	if records := read("2001:db8::1"); len(records) != 1 {
+		t.Errorf("2001:db8::1: got %v", records)
+	}
+	if records := read("198.51.100.1"); len(records) != 0 {
+		t.Errorf("198.51.100.1: got %v", records)
+	}
+}
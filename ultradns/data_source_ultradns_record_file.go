@@ -0,0 +1,223 @@
+package ultradns
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// recordFileDefaultTTL is the TTL of records that have none in the file,
+// the same as the default of ultradns_record
+const recordFileDefaultTTL = 3600
+
+func dataSourceUltradnsRecordFile() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsRecordFileRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"path": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Optional
+			"format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"csv", "json"}, false),
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			// Computed
+			"records": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ttl": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"rdata": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsRecordFileRead(d *schema.ResourceData, meta interface{}) error {
+	path := d.Get("path").(string)
+	format := d.Get("format").(string)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s failed: %v", path, err)
+	}
+	records, err := parseRecordFile(b, format, d.Get("zone").(string))
+	if err != nil {
+		return fmt.Errorf("parsing %s failed: %v", path, err)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(string(b))))
+	err = d.Set("records", records)
+	if err != nil {
+		return fmt.Errorf("records set failed: %v", err)
+	}
+	return nil
+}
+
+// recordFileEntry is one row of a CSV record file, or one object of a
+// JSON one. Rdata may be a string or a list of strings in JSON.
+type recordFileEntry struct {
+	Owner string          `json:"owner"`
+	Type  string          `json:"type"`
+	TTL   *int            `json:"ttl"`
+	RData json.RawMessage `json:"rdata"`
+}
+
+// parseRecordFile parses a CSV or JSON file of records, merging entries
+// with the same owner and type into one RRSet, and returns the RRSets
+// ordered by key in the appropriate structure for the schema. Owner
+// names in zone are made relative to it.
+func parseRecordFile(b []byte, format, zone string) ([]map[string]interface{}, error) {
+	var entries []recordFileEntry
+	var err error
+	switch format {
+	case "csv":
+		entries, err = parseRecordFileCSV(b)
+	case "json":
+		err = json.Unmarshal(b, &entries)
+	default:
+		return nil, fmt.Errorf("unknown format %q; set format to csv or json", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]map[string]interface{}{}
+	for i, e := range entries {
+		if e.Owner == "" || e.Type == "" {
+			return nil, fmt.Errorf("record %d: owner and type are required", i+1)
+		}
+		rdata, err := recordFileRData(e.RData)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", i+1, err)
+		}
+		name := e.Owner
+		if zone != "" {
+			name = normalizeOwnerName(name, zone)
+		}
+		typ := strings.ToUpper(e.Type)
+		ttl := recordFileDefaultTTL
+		if e.TTL != nil {
+			ttl = *e.TTL
+		}
+		if ttl < 0 || ttl > maxTTL {
+			return nil, fmt.Errorf("record %d: ttl %d is not between 0 and %d", i+1, ttl, maxTTL)
+		}
+
+		key := fmt.Sprintf("%s %s", strings.ToLower(name), typ)
+		m, ok := byKey[key]
+		if !ok {
+			byKey[key] = map[string]interface{}{"key": key, "name": name, "type": typ, "ttl": ttl, "rdata": rdata}
+			continue
+		}
+		if e.TTL != nil && m["ttl"].(int) != ttl {
+			return nil, fmt.Errorf("record %d: ttl %d differs from the ttl %d of the other %s records", i+1, ttl, m["ttl"], key)
+		}
+		m["rdata"] = append(m["rdata"].([]string), rdata...)
+	}
+
+	keys := []string{}
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	records := make([]map[string]interface{}, len(keys))
+	for i, k := range keys {
+		records[i] = byKey[k]
+	}
+	return records, nil
+}
+
+// parseRecordFileCSV parses the columns owner, type, ttl and rdata, in
+// that order. A header row naming them is skipped, and ttl may be empty.
+func parseRecordFileCSV(b []byte) ([]recordFileEntry, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = 4
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	entries := []recordFileEntry{}
+	for line := 1; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(row[0], "owner") {
+			continue
+		}
+		e := recordFileEntry{Owner: row[0], Type: row[1]}
+		if row[2] != "" {
+			ttl, err := strconv.Atoi(row[2])
+			if err != nil {
+				return nil, fmt.Errorf("row %d: ttl %q is not a number", line, row[2])
+			}
+			e.TTL = &ttl
+		}
+		e.RData, _ = json.Marshal(row[3])
+		entries = append(entries, e)
+	}
+}
+
+// recordFileRData decodes rdata given as a string or a list of strings
+func recordFileRData(raw json.RawMessage) ([]string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s == "" {
+			return nil, fmt.Errorf("rdata is required")
+		}
+		return []string{s}, nil
+	}
+	var ss []string
+	if err := json.Unmarshal(raw, &ss); err != nil || len(ss) == 0 {
+		return nil, fmt.Errorf("rdata must be a string or a non-empty list of strings")
+	}
+	return ss, nil
+}
@@ -0,0 +1,83 @@
+package ultradns
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestDataSourceUltradnsRecordFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ultradns-record-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "records.CSV")
+	err = ioutil.WriteFile(path, []byte("www,A,300,192.0.2.1\nwww,A,,192.0.2.2\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceUltradnsRecordFile().Schema, map[string]interface{}{"path": path})
+	err = dataSourceUltradnsRecordFileRead(d, nil)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got := d.Get("records.0.key"); got != "www A" {
+		t.Errorf("records.0.key = %v", got)
+	}
+	if got := d.Get("records.0.rdata"); !reflect.DeepEqual(got, []interface{}{"192.0.2.1", "192.0.2.2"}) {
+		t.Errorf("records.0.rdata = %v", got)
+	}
+}
+
+func TestParseRecordFile(t *testing.T) {
+	csv := `owner,type,ttl,rdata
+www.example.com.,a,300,192.0.2.1
+www,A,,192.0.2.2
+# a comment
+@,TXT,,"""v=spf1 -all"""
+mail,MX,600,10 mx.example.com.
+`
+	want := []map[string]interface{}{
+		{"key": "@ TXT", "name": "@", "type": "TXT", "ttl": 3600, "rdata": []string{`"v=spf1 -all"`}},
+		{"key": "mail MX", "name": "mail", "type": "MX", "ttl": 600, "rdata": []string{"10 mx.example.com."}},
+		{"key": "www A", "name": "www", "type": "A", "ttl": 300, "rdata": []string{"192.0.2.1", "192.0.2.2"}},
+	}
+	got, err := parseRecordFile([]byte(csv), "csv", "example.com")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("csv:\n got %v, %v\nwant %v", got, err, want)
+	}
+
+	json := `[
+  {"owner": "www.example.com.", "type": "a", "ttl": 300, "rdata": ["192.0.2.1", "192.0.2.2"]},
+  {"owner": "@", "type": "TXT", "rdata": "\"v=spf1 -all\""},
+  {"owner": "mail", "type": "MX", "ttl": 600, "rdata": "10 mx.example.com."}
+]`
+	got, err = parseRecordFile([]byte(json), "json", "example.com")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("json:\n got %v, %v\nwant %v", got, err, want)
+	}
+
+	for _, c := range []struct {
+		format  string
+		content string
+	}{
+		{"csv", "www,A,300\n"},
+		{"csv", "www,A,abc,192.0.2.1\n"},
+		{"csv", "www,A,300,192.0.2.1\nwww,A,600,192.0.2.2\n"},
+		{"csv", ",A,300,192.0.2.1\n"},
+		{"json", `[{"owner": "www", "type": "A", "rdata": []}]`},
+		{"json", `[{"owner": "www", "type": "A", "rdata": "192.0.2.1", "ttl": -1}]`},
+		{"json", `{"owner": "www"}`},
+		{"yaml", "www: A"},
+	} {
+		if _, err := parseRecordFile([]byte(c.content), c.format, ""); err == nil {
+			t.Errorf("%s %q: expected an error", c.format, c.content)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ultradns
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// dataSourceUltradnsRecordTypes lists the record and pool types the
+// provider can address. UltraDNS has no endpoint describing what an
+// account supports, so no API call is made.
+func dataSourceUltradnsRecordTypes() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsRecordTypesRead,
+
+		Schema: map[string]*schema.Schema{
+			// Computed
+			"types": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Set:      schema.HashString,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"codes": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+			},
+			"pool_types": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"profile": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"resource": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsRecordTypesRead(d *schema.ResourceData, meta interface{}) error {
+	types := []string{}
+	codes := map[string]interface{}{}
+	for t, code := range rrTypeCodes {
+		types = append(types, t)
+		codes[t] = code
+	}
+
+	d.SetId("record_types")
+	err := d.Set("types", makeSetFromStrings(types))
+	if err != nil {
+		return fmt.Errorf("types set failed: %v", err)
+	}
+	err = d.Set("codes", codes)
+	if err != nil {
+		return fmt.Errorf("codes set failed: %v", err)
+	}
+	err = d.Set("pool_types", makePoolTypes())
+	if err != nil {
+		return fmt.Errorf("pool_types set failed: %v", err)
+	}
+	return nil
+}
+
+// makePoolTypes encodes poolProfileTypes, ordered by description, in the
+// appropriate structure for the schema
+func makePoolTypes() []map[string]interface{} {
+	pools := []map[string]interface{}{}
+	for profile, t := range poolProfileTypes {
+		pools = append(pools, map[string]interface{}{
+			"profile":     string(profile),
+			"description": t[0],
+			"resource":    t[1],
+		})
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i]["description"].(string) < pools[j]["description"].(string)
+	})
+	return pools
+}
@@ -0,0 +1,35 @@
+package ultradns
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestDataSourceUltradnsRecordTypes(t *testing.T) {
+	ds := dataSourceUltradnsRecordTypes()
+	d := schema.TestResourceDataRaw(t, ds.Schema, map[string]interface{}{})
+	err := dataSourceUltradnsRecordTypesRead(d, nil)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	types := d.Get("types").(*schema.Set)
+	for _, typ := range []string{"A", "AAAA", "CAA", "TXT"} {
+		if !types.Contains(typ) {
+			t.Errorf("types %v is missing %s", types.List(), typ)
+		}
+	}
+	if got := d.Get("codes.AAAA"); got != 28 {
+		t.Errorf("codes.AAAA = %v, want 28", got)
+	}
+	if got := d.Get("pool_types.#"); got != len(poolProfileTypes) {
+		t.Errorf("pool_types.# = %v, want %d", got, len(poolProfileTypes))
+	}
+	if got := d.Get("pool_types.0.description"); got != "Directional pool" {
+		t.Errorf("pool_types.0.description = %v, want Directional pool", got)
+	}
+	if got := d.Get("pool_types.0.resource"); got != "ultradns_dirpool" {
+		t.Errorf("pool_types.0.resource = %v, want ultradns_dirpool", got)
+	}
+}
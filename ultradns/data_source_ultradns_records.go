@@ -0,0 +1,118 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
+)
+
+func dataSourceUltradnsRecords() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsRecordsRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Optional
+			"name_filter": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"ttl_filter": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(0, maxTTL),
+			},
+			"value_filter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringDoesNotContainAny(" \t"),
+			},
+			// Computed
+			"records": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"hostname": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ttl": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"rdata": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsRecordsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	q := rRSetQuery{
+		Zone:  d.Get("zone").(string),
+		Type:  strings.ToUpper(d.Get("type").(string)),
+		Owner: d.Get("name_filter").(string),
+		TTL:   d.Get("ttl_filter").(int),
+		Value: d.Get("value_filter").(string),
+	}
+	log.Printf("[INFO] ultradns_records read: %#v", q)
+	records := []map[string]interface{}{}
+	err := eachRRSet(client, q, func(r udnssdk.RRSet) error {
+		records = append(records, mapFromRRSet(r, q.Zone))
+		return nil
+	})
+	if err != nil && !isRRSetNotFound(err) {
+		return fmt.Errorf("rrsets list failed: %v", err)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(fmt.Sprintf("%s:%s:%s", q.Zone, q.Type, q.Filter()))))
+	err = d.Set("records", records)
+	if err != nil {
+		return fmt.Errorf("records set failed: %v", err)
+	}
+	return nil
+}
+
+// mapFromRRSet encodes an RRSet into a map[string]interface{} in the
+// appropriate structure for the records schema
+func mapFromRRSet(r udnssdk.RRSet, zone string) map[string]interface{} {
+	typ := rrTypeName(r.RRType)
+	rdata := r.RData
+	if typ == "TXT" {
+		rdata = make([]string, len(r.RData))
+		for i := range r.RData {
+			rdata[i] = normalizeTXTRdata(r.RData[i])
+		}
+	}
+	return map[string]interface{}{
+		"hostname": fqdnOwner(r.OwnerName, zone),
+		"type":     typ,
+		"ttl":      r.TTL,
+		"rdata":    rdata,
+	}
+}
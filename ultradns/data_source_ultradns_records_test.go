@@ -0,0 +1,48 @@
+package ultradns
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestAccDataSourceUltradnsRecords(t *testing.T) {
+	domain := "ultradns.phinze.com"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccRecordCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCfgDataSourceRecords, domain, domain),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ultradns_records.it", "records.#", "1"),
+					resource.TestCheckResourceAttr("data.ultradns_records.it", "records.0.hostname", "test-records.ultradns.phinze.com."),
+					resource.TestCheckResourceAttr("data.ultradns_records.it", "records.0.type", "CNAME"),
+					resource.TestCheckResourceAttr("data.ultradns_records.it", "records.0.ttl", "300"),
+					resource.TestCheckResourceAttr("data.ultradns_records.it", "records.0.rdata.0", "cdn.example.net."),
+				),
+			},
+		},
+	})
+}
+
+const testCfgDataSourceRecords = `
+resource "ultradns_record" "it" {
+  zone  = "%s"
+  name  = "test-records"
+  rdata = ["cdn.example.net."]
+  type  = "CNAME"
+  ttl   = 300
+}
+
+data "ultradns_records" "it" {
+  zone        = "%s"
+  type        = "CNAME"
+  name_filter = "test-records"
+
+  depends_on = ["ultradns_record.it"]
+}
+`
@@ -0,0 +1,99 @@
+package ultradns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func dataSourceUltradnsSPFFlattened() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsSPFFlattenedRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"policy": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Optional
+			"lookup_budget": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      50,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			// Computed
+			"value": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lookups": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"chunks": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsSPFFlattenedRead(d *schema.ResourceData, meta interface{}) error {
+	policy := d.Get("policy").(string)
+	log.Printf("[INFO] ultradns_spf_flattened read: %q", policy)
+
+	f := &spfFlattener{
+		lookupTXT: func(name string) ([]string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+			defer cancel()
+			return net.DefaultResolver.LookupTXT(ctx, name)
+		},
+		lookupIP: func(name string) ([]net.IP, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+			defer cancel()
+			addrs, err := net.DefaultResolver.LookupIPAddr(ctx, name)
+			ips := make([]net.IP, len(addrs))
+			for i, a := range addrs {
+				ips[i] = a.IP
+			}
+			return ips, err
+		},
+		lookupMX: func(name string) ([]string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+			defer cancel()
+			mxs, err := net.DefaultResolver.LookupMX(ctx, name)
+			hosts := make([]string, len(mxs))
+			for i, mx := range mxs {
+				hosts[i] = mx.Host
+			}
+			return hosts, err
+		},
+		budget: d.Get("lookup_budget").(int),
+	}
+	value, err := f.flatten(policy)
+	if err != nil {
+		return err
+	}
+	lookups, err := parseSPF(value)
+	if err != nil {
+		return fmt.Errorf("flattened policy is malformed: %v", err)
+	}
+	log.Printf("[DEBUG] ultradns_spf_flattened: %d DNS lookups made, %d left in %q", f.used, lookups, value)
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(value)))
+	d.Set("value", value)
+	d.Set("lookups", lookups)
+	err = d.Set("chunks", chunkString(value, txtStringLimit))
+	if err != nil {
+		return fmt.Errorf("chunks set failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceUltradnsTerritories() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsTerritoriesRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"region": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Computed
+			"region_code": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"codes": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Set:      schema.HashString,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsTerritoriesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	region := d.Get("region").(string)
+	log.Printf("[INFO] ultradns_territories read: region: %q", region)
+
+	t, err := findRegion(client, region)
+	if err != nil {
+		return fmt.Errorf("territories list failed: %v", err)
+	}
+	codes, err := expandTerritory(client, t)
+	if err != nil {
+		return fmt.Errorf("territories list failed: %v", err)
+	}
+
+	d.SetId(t.Code)
+	d.Set("region_code", t.Code)
+	err = d.Set("codes", makeSetFromStrings(codes))
+	if err != nil {
+		return fmt.Errorf("codes set failed: %v", err)
+	}
+	return nil
+}
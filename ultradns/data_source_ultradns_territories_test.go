@@ -0,0 +1,29 @@
+package ultradns
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestAccDataSourceUltradnsTerritories(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testCfgDataSourceTerritories,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ultradns_territories.it", "region_code", "EUR"),
+					resource.TestCheckResourceAttrSet("data.ultradns_territories.it", "codes.#"),
+				),
+			},
+		},
+	})
+}
+
+const testCfgDataSourceTerritories = `
+data "ultradns_territories" "it" {
+  region = "europe"
+}
+`
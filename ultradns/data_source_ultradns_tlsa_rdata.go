@@ -0,0 +1,103 @@
+package ultradns
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func dataSourceUltradnsTLSARdata() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsTLSARdataRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"certificate": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Optional
+			"usage": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntBetween(0, 3),
+			},
+			"selector": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntBetween(0, 1),
+			},
+			"matching_type": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntBetween(0, 2),
+			},
+			// Computed
+			"rdata": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsTLSARdataRead(d *schema.ResourceData, meta interface{}) error {
+	rdata, err := tlsaRdata(d.Get("certificate").(string), d.Get("usage").(int), d.Get("selector").(int), d.Get("matching_type").(int))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(rdata)))
+	d.Set("rdata", rdata)
+	return nil
+}
+
+// tlsaRdata renders the RFC 6698 TLSA rdata for the first certificate in
+// certPEM: "usage selector matching-type data". Selector 0 matches the
+// whole certificate and 1 its SubjectPublicKeyInfo; matching type 0 is
+// the data itself, 1 its SHA-256 and 2 its SHA-512.
+func tlsaRdata(certPEM string, usage, selector, matchingType int) (string, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("certificate must be a PEM encoded CERTIFICATE block")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("certificate parse failed: %v", err)
+	}
+
+	var data []byte
+	switch selector {
+	case 0:
+		data = cert.Raw
+	case 1:
+		data = cert.RawSubjectPublicKeyInfo
+	default:
+		return "", fmt.Errorf("unknown TLSA selector %d", selector)
+	}
+
+	switch matchingType {
+	case 0:
+	case 1:
+		sum := sha256.Sum256(data)
+		data = sum[:]
+	case 2:
+		sum := sha512.Sum512(data)
+		data = sum[:]
+	default:
+		return "", fmt.Errorf("unknown TLSA matching type %d", matchingType)
+	}
+
+	return fmt.Sprintf("%d %d %d %s", usage, selector, matchingType, strings.ToUpper(hex.EncodeToString(data))), nil
+}
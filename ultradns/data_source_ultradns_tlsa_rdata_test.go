@@ -0,0 +1,68 @@
+package ultradns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate in PEM form
+func testCertificate(t *testing.T) (string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), cert
+}
+
+func TestTLSARdata(t *testing.T) {
+	certPEM, cert := testCertificate(t)
+
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	full := strings.ToUpper(hex.EncodeToString(cert.Raw))
+	cases := []struct {
+		usage, selector, matchingType int
+		want                          string
+	}{
+		{3, 1, 1, fmt.Sprintf("3 1 1 %s", strings.ToUpper(hex.EncodeToString(spki[:])))},
+		{2, 0, 0, fmt.Sprintf("2 0 0 %s", full)},
+	}
+	for _, c := range cases {
+		got, err := tlsaRdata(certPEM, c.usage, c.selector, c.matchingType)
+		if err != nil || got != c.want {
+			t.Errorf("tlsaRdata(%d, %d, %d) = %q, %v, want %q", c.usage, c.selector, c.matchingType, got, err, c.want)
+		}
+	}
+
+	got, err := tlsaRdata(certPEM, 3, 0, 2)
+	if err != nil || len(got) != len("3 0 2 ")+128 {
+		t.Errorf("tlsaRdata SHA-512: got %q, %v", got, err)
+	}
+	if _, err := tlsaRdata("not a certificate", 3, 1, 1); err == nil {
+		t.Error("tlsaRdata of garbage: expected an error")
+	}
+}
@@ -0,0 +1,72 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceUltradnsZoneTransferStatus() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsZoneTransferStatusRead,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// Computed
+			"last_refresh": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"next_refresh": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_refresh_status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_refresh_status_message": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"serial": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsZoneTransferStatusRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	zone := d.Get("zone").(string)
+	log.Printf("[INFO] ultradns_zone_transfer_status read: zone: %q", zone)
+
+	z, err := findZone(client, zone)
+	if err != nil {
+		return fmt.Errorf("zone %q lookup failed: %v", zone, err)
+	}
+	ts := z.TransferStatusDetails
+	if z.Properties.Type != "SECONDARY" || ts == nil {
+		return fmt.Errorf("zone %q is a %s zone; transfer status is only available for SECONDARY zones", zone, z.Properties.Type)
+	}
+
+	serial, err := findZoneSerial(client, zone)
+	if err != nil {
+		return fmt.Errorf("zone %q serial lookup failed: %v", zone, err)
+	}
+
+	d.SetId(z.Properties.Name)
+	d.Set("last_refresh", ts.LastRefresh)
+	d.Set("next_refresh", ts.NextRefresh)
+	d.Set("last_refresh_status", ts.LastRefreshStatus)
+	d.Set("last_refresh_status_message", ts.LastRefreshStatusMessage)
+	d.Set("serial", serial)
+	return nil
+}
@@ -0,0 +1,125 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceUltradnsZones() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceUltradnsZonesRead,
+
+		Schema: map[string]*schema.Schema{
+			// Optional
+			"name_filter": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"include_serial": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			// Computed
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"zones": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"account_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"dnssec_status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"resource_record_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"last_modified": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"serial": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceUltradnsZonesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	filter := d.Get("name_filter").(string)
+	includeSerial := d.Get("include_serial").(bool)
+	log.Printf("[INFO] ultradns_zones read: name_filter: %q, include_serial: %v", filter, includeSerial)
+
+	names := []string{}
+	zones := []map[string]interface{}{}
+	err := selectZones(client, filter, func(z zoneDTO) error {
+		zone := mapFromZoneProperties(z.Properties)
+		// The serial costs one more call per zone, so it is opt-in
+		if includeSerial {
+			serial, err := findZoneSerial(client, z.Properties.Name)
+			if err != nil {
+				log.Printf("[WARN] ultradns_zones: reading the serial of zone %q failed, leaving it 0: %v", z.Properties.Name, err)
+			}
+			zone["serial"] = serial
+		}
+
+		names = append(names, z.Properties.Name)
+		zones = append(zones, zone)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("zones list failed: %v", err)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(filter)))
+	d.Set("names", names)
+	err = d.Set("zones", zones)
+	if err != nil {
+		return fmt.Errorf("zones set failed: %v", err)
+	}
+	return nil
+}
+
+// mapFromZoneProperties encodes zoneProperties into a map[string]interface{}
+// in the appropriate structure for the schema
+func mapFromZoneProperties(p zoneProperties) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                  p.Name,
+		"account_name":          p.AccountName,
+		"type":                  p.Type,
+		"status":                p.Status,
+		"dnssec_status":         p.DNSSECStatus,
+		"resource_record_count": p.ResourceRecordCount,
+		"last_modified":         p.LastModified,
+	}
+}
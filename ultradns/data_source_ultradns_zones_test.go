@@ -0,0 +1,84 @@
+package ultradns
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestAccDataSourceUltradnsZones(t *testing.T) {
+	domain := "ultradns.phinze.com"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCfgDataSourceZones, domain),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ultradns_zones.it", "names.#", "1"),
+					resource.TestCheckResourceAttr("data.ultradns_zones.it", "names.0", "ultradns.phinze.com."),
+					resource.TestCheckResourceAttr("data.ultradns_zones.it", "zones.0.name", "ultradns.phinze.com."),
+					resource.TestCheckResourceAttr("data.ultradns_zones.it", "zones.0.type", "PRIMARY"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceUltradnsZonesRead_includeSerial(t *testing.T) {
+	soaCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v3/zones"):
+			fmt.Fprint(w, `{"zones": [{"properties": {"name": "a.example."}}, {"properties": {"name": "b.example."}}], "cursorInfo": {}}`)
+		case strings.HasPrefix(r.URL.Path, "/v1/zones/a.example./rrsets/SOA"):
+			soaCalls++
+			fmt.Fprint(w, `{"rrSets": [{"ownerName": "a.example.", "rrtype": "SOA (6)", "rdata": ["ns.example. hostmaster.example. 42 3600 600 86400 300"]}],
+				"resultInfo": {"totalCount": 1, "offset": 0, "returnedCount": 1}}`)
+		default:
+			soaCalls++
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `[{"errorCode": 1801, "errorMessage": "Zone does not exist in the system."}]`)
+		}
+	}))
+	defer ts.Close()
+	client, err := (&Config{AccessToken: "a1", BaseURL: ts.URL}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+
+	read := func(includeSerial bool) []interface{} {
+		d := schema.TestResourceDataRaw(t, dataSourceUltradnsZones().Schema, map[string]interface{}{"include_serial": includeSerial})
+		if err := dataSourceUltradnsZonesRead(d, client); err != nil {
+			t.Fatalf("read with include_serial = %v: %v", includeSerial, err)
+		}
+		return d.Get("zones").([]interface{})
+	}
+
+	if zones := read(false); len(zones) != 2 || soaCalls != 0 {
+		t.Errorf("without include_serial: %d zones, %d SOA calls", len(zones), soaCalls)
+	}
+
+	// A failed lookup leaves that zone's serial 0
+	zones := read(true)
+	if len(zones) != 2 || soaCalls != 2 {
+		t.Fatalf("with include_serial: %d zones, %d SOA calls", len(zones), soaCalls)
+	}
+	serials := []interface{}{zones[0].(map[string]interface{})["serial"], zones[1].(map[string]interface{})["serial"]}
+	if fmt.Sprint(serials) != "[42 0]" {
+		t.Errorf("serials = %v, want [42 0]", serials)
+	}
+}
+
+const testCfgDataSourceZones = `
+data "ultradns_zones" "it" {
+  name_filter = "%s"
+}
+`
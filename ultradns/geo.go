@@ -0,0 +1,93 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// udnssdk has no GeoIP service, so the territory endpoint used by this
+// provider is described here.
+
+// territoryDTO wraps a single entry of the territories index
+type territoryDTO struct {
+	ID         int    `json:"id"`
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	ChildCount int    `json:"childCount"`
+}
+
+// territoriesURI generates the territories index URI for a list of
+// parent paths. A path is a chain of codes joined by "-", e.g. "NAM-US";
+// the empty path lists the top-level regions.
+func territoriesURI(paths []string) string {
+	v := url.Values{}
+	v.Set("codes", strings.Join(paths, ","))
+	return fmt.Sprintf("geoip/territories?%s", v.Encode())
+}
+
+// listTerritories returns the children of each parent path, in the order
+// the paths were given
+func listTerritories(client *Client, paths []string) ([][]territoryDTO, error) {
+	var res [][]territoryDTO
+	_, err := client.Do("GET", territoriesURI(paths), nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != len(paths) {
+		return nil, fmt.Errorf("territories: asked for %d parents, got %d", len(paths), len(res))
+	}
+	return res, nil
+}
+
+// findRegion returns the top-level region whose name or code matches
+// region, case-insensitively
+func findRegion(client *Client, region string) (territoryDTO, error) {
+	res, err := listTerritories(client, []string{""})
+	if err != nil {
+		return territoryDTO{}, err
+	}
+
+	names := []string{}
+	for _, t := range res[0] {
+		if strings.EqualFold(t.Name, region) || strings.EqualFold(t.Code, region) {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+	return territoryDTO{}, fmt.Errorf("no region named %q, expected one of: %s", region, strings.Join(names, ", "))
+}
+
+// expandTerritory returns the codes of every leaf territory below the
+// top-level territory t. The tree is walked one level at a time, so each
+// level costs a single request however many territories it has.
+func expandTerritory(client *Client, t territoryDTO) ([]string, error) {
+	if t.ChildCount == 0 {
+		return []string{t.Code}, nil
+	}
+
+	leaves := []string{}
+	paths := []string{t.Code}
+	for len(paths) > 0 {
+		res, err := listTerritories(client, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		next := []string{}
+		for i, children := range res {
+			for _, c := range children {
+				if c.ChildCount == 0 {
+					leaves = append(leaves, c.Code)
+					continue
+				}
+				next = append(next, fmt.Sprintf("%s-%s", paths[i], c.Code))
+			}
+		}
+		log.Printf("[DEBUG] expandTerritory(%q): %d leaves, %d parents to expand", t.Code, len(leaves), len(next))
+		paths = next
+	}
+	return leaves, nil
+}
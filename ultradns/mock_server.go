@@ -0,0 +1,469 @@
+package ultradns
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+// mockServer is an in-memory stand-in for the parts of the UltraDNS REST
+// API that this provider uses for records, pools and zones, so that
+// acceptance tests and local development can run without credentials.
+//
+// Every zone exists: a zone is created, with SOA and NS records, the
+// first time a request names it. Endpoints it does not simulate, such
+// as probes, answer 404.
+//
+// With a stateFile the zones are loaded from it at start and written back
+// after every request that may change them, so that the separate provider
+// processes Terraform starts for plan and apply see the same zones.
+type mockServer struct {
+	mu        sync.Mutex
+	zones     map[string]*mockZone
+	stateFile string
+}
+
+// mockZone holds a zone's properties and its RRSets, keyed by
+// mockRRSetKey
+type mockZone struct {
+	properties zoneProperties
+	rrsets     map[string]udnssdk.RRSet
+}
+
+// mockState is the content of a mock state file
+type mockState struct {
+	Zones []mockZoneState `json:"zones"`
+}
+
+type mockZoneState struct {
+	Properties zoneProperties  `json:"properties"`
+	RRSets     []udnssdk.RRSet `json:"rrSets"`
+}
+
+// newMockServer starts a mock UltraDNS API, with the zones of stateFile
+// if it is set and exists, and returns it with the server it listens on
+func newMockServer(stateFile string) (*mockServer, *httptest.Server, error) {
+	m := &mockServer{zones: map[string]*mockZone{}, stateFile: stateFile}
+	if stateFile != "" {
+		if err := m.load(); err != nil {
+			return nil, nil, fmt.Errorf("reading the mock state file %s: %v", stateFile, err)
+		}
+	}
+	return m, httptest.NewServer(m), nil
+}
+
+// load reads the zones of m.stateFile; a missing file holds none
+func (m *mockServer) load() error {
+	b, err := ioutil.ReadFile(m.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state mockState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return err
+	}
+	for _, zs := range state.Zones {
+		z := &mockZone{properties: zs.Properties, rrsets: map[string]udnssdk.RRSet{}}
+		for _, r := range zs.RRSets {
+			z.rrsets[mockRRSetKey(r.RRType, r.OwnerName)] = r
+		}
+		m.zones[zs.Properties.Name] = z
+	}
+	return nil
+}
+
+// save replaces m.stateFile atomically with the zones of m, in name
+// order. The caller holds m.mu.
+func (m *mockServer) save() error {
+	names := make([]string, 0, len(m.zones))
+	for name := range m.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	state := mockState{Zones: make([]mockZoneState, len(names))}
+	for i, name := range names {
+		z := m.zones[name]
+		state.Zones[i] = mockZoneState{Properties: z.properties, RRSets: z.find("ANY", "")}
+	}
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(m.stateFile), filepath.Base(m.stateFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), m.stateFile)
+}
+
+// mockServers holds the mock API of each account, keyed by username, or
+// of each state file, so that every Configure of a process, and every
+// provider instance configured for the same account or state file, sees
+// the same zones
+var mockServers = struct {
+	sync.Mutex
+	m map[string]*httptest.Server
+}{m: map[string]*httptest.Server{}}
+
+// sharedMockServer returns the mock API of stateFile, if set, or else of
+// the account username, starting it on first use
+func sharedMockServer(username, stateFile string) (*httptest.Server, error) {
+	key := "username " + username
+	if stateFile != "" {
+		abs, err := filepath.Abs(stateFile)
+		if err != nil {
+			return nil, err
+		}
+		key = "file " + abs
+	}
+
+	mockServers.Lock()
+	defer mockServers.Unlock()
+	if server, ok := mockServers.m[key]; ok {
+		return server, nil
+	}
+	_, server, err := newMockServer(stateFile)
+	if err != nil {
+		return nil, err
+	}
+	mockServers.m[key] = server
+	return server, nil
+}
+
+// CloseMockServers shuts down the mock APIs started by clients configured
+// with Mock. Clients still using them fail from then on.
+func CloseMockServers() {
+	mockServers.Lock()
+	defer mockServers.Unlock()
+	for key, server := range mockServers.m {
+		server.Close()
+		delete(mockServers.m, key)
+	}
+}
+
+func mockRRSetKey(rrtype, owner string) string {
+	return fmt.Sprintf("%s %s", strings.ToUpper(rrTypeName(rrtype)), strings.ToLower(owner))
+}
+
+// zone returns the named zone, creating it if needed. The caller holds m.mu.
+func (m *mockServer) zone(name string) *mockZone {
+	name = strings.ToLower(strings.TrimSuffix(name, ".") + ".")
+	if z, ok := m.zones[name]; ok {
+		return z
+	}
+	z := &mockZone{
+		properties: zoneProperties{
+			Name:         name,
+			AccountName:  "mock",
+			Type:         "PRIMARY",
+			DNSSECStatus: "UNSIGNED",
+			Status:       "ACTIVE",
+		},
+		rrsets: map[string]udnssdk.RRSet{},
+	}
+	z.put(udnssdk.RRSet{
+		OwnerName: name,
+		RRType:    "SOA",
+		TTL:       86400,
+		RData:     []string{fmt.Sprintf("pdns1.ultradns.net. hostmaster.%s 1 86400 86400 86400 86400", name)},
+	})
+	z.put(udnssdk.RRSet{
+		OwnerName: name,
+		RRType:    "NS",
+		TTL:       86400,
+		RData:     []string{"pdns1.ultradns.net.", "pdns2.ultradns.net."},
+	})
+	m.zones[name] = z
+	return z
+}
+
+// put stores r in the form the API returns it, with an absolute owner
+// name and a numbered RRType
+func (z *mockZone) put(r udnssdk.RRSet) {
+	r.OwnerName = fqdnOwner(r.OwnerName, z.properties.Name)
+	typ := strings.ToUpper(rrTypeName(r.RRType))
+	r.RRType = typ
+	if code, ok := rrTypeCodes[typ]; ok {
+		r.RRType = fmt.Sprintf("%s (%d)", typ, code)
+	}
+	z.rrsets[mockRRSetKey(typ, r.OwnerName)] = r
+	z.properties.ResourceRecordCount = len(z.rrsets)
+}
+
+// bumpSerial increments the SOA serial, as UltraDNS does on every change
+func (z *mockZone) bumpSerial() {
+	key := mockRRSetKey("SOA", z.properties.Name)
+	soa, ok := z.rrsets[key]
+	if !ok || len(soa.RData) == 0 {
+		return
+	}
+	serial, err := parseSOASerial(soa.RData[0])
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(soa.RData[0])
+	fields[2] = strconv.Itoa(serial + 1)
+	soa.RData = []string{strings.Join(fields, " ")}
+	z.rrsets[key] = soa
+}
+
+// find returns the RRSets of zone matching rrtype and owner, in a stable
+// order. An rrtype of "ANY" or an empty owner matches everything.
+func (z *mockZone) find(rrtype, owner string) []udnssdk.RRSet {
+	keys := []string{}
+	for k, r := range z.rrsets {
+		if rrtype != "ANY" && !strings.EqualFold(rrTypeName(r.RRType), rrtype) {
+			continue
+		}
+		if owner != "" && !strings.EqualFold(r.OwnerName, fqdnOwner(owner, z.properties.Name)) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rrsets := make([]udnssdk.RRSet, len(keys))
+	for i, k := range keys {
+		rrsets[i] = z.rrsets[k]
+	}
+	return rrsets
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serve(w, r)
+	if m.stateFile != "" && r.Method != "GET" {
+		if err := m.save(); err != nil {
+			log.Printf("[ERROR] writing the mock state file %s failed: %v", m.stateFile, err)
+		}
+	}
+}
+
+// serve answers r; the caller holds m.mu
+func (m *mockServer) serve(w http.ResponseWriter, r *http.Request) {
+	// udnssdk builds the token URL with a doubled slash. Owner names
+	// are percent-encoded, and may contain "/".
+	path := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for i, seg := range path {
+		if s, err := url.PathUnescape(seg); err == nil {
+			path[i] = s
+		}
+	}
+	log.Printf("[DEBUG] mock UltraDNS API: %s %s", r.Method, r.URL)
+
+	switch {
+	case len(path) == 3 && path[0] == "v1" && path[1] == "authorization" && path[2] == "token":
+		mockRespond(w, r, http.StatusOK, map[string]interface{}{
+			"access_token":  "mock",
+			"refresh_token": "mock",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	case len(path) == 2 && path[0] == "v1" && path[1] == "batch" && r.Method == "POST":
+		m.serveBatch(w, r)
+	case len(path) == 2 && path[0] == "v3" && path[1] == "zones" && r.Method == "GET":
+		m.listZones(w, r)
+	case len(path) == 3 && path[0] == "v1" && path[1] == "zones" && r.Method == "GET":
+		mockRespond(w, r, http.StatusOK, zoneDTO{Properties: m.zone(path[2]).properties})
+	case len(path) >= 4 && len(path) <= 6 && path[0] == "v1" && path[1] == "zones" && path[3] == "rrsets":
+		rrtype, owner := "ANY", ""
+		if len(path) > 4 {
+			rrtype = strings.ToUpper(path[4])
+		}
+		if len(path) > 5 {
+			owner = path[5]
+		}
+		m.serveRRSets(w, r, m.zone(path[2]), rrtype, owner)
+	default:
+		mockError(w, r, http.StatusNotFound, 0, fmt.Sprintf("%s %s is not simulated by the mock UltraDNS API", r.Method, r.URL.Path))
+	}
+}
+
+// serveBatch runs the calls of a batch in order, each as if it were
+// sent on its own, and answers with their results
+func (m *mockServer) serveBatch(w http.ResponseWriter, r *http.Request) {
+	var calls []batchCall
+	if err := json.NewDecoder(r.Body).Decode(&calls); err != nil {
+		mockError(w, r, http.StatusBadRequest, 0, fmt.Sprintf("Malformed batch: %v", err))
+		return
+	}
+
+	results := make([]batchResult, len(calls))
+	for i, c := range calls {
+		body, _ := json.Marshal(c.Body)
+		rec := httptest.NewRecorder()
+		m.serve(rec, httptest.NewRequest(c.Method, c.URI, bytes.NewReader(body)))
+		results[i] = batchResult{Code: rec.Code, Body: json.RawMessage(bytes.TrimSpace(rec.Body.Bytes()))}
+	}
+	mockRespond(w, r, http.StatusOK, results)
+}
+
+// listZones serves the v3 zones index. Like any other request, a name
+// filter that names a zone creates it.
+func (m *mockServer) listZones(w http.ResponseWriter, r *http.Request) {
+	filter := strings.TrimPrefix(r.URL.Query().Get("q"), "name:")
+	if strings.Contains(filter, ".") {
+		m.zone(filter)
+	}
+
+	names := []string{}
+	for name := range m.zones {
+		if strings.Contains(name, strings.ToLower(filter)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	page := zoneListDTO{Zones: []zoneDTO{}}
+	for _, name := range names {
+		page.Zones = append(page.Zones, zoneDTO{Properties: m.zones[name].properties})
+	}
+	mockRespond(w, r, http.StatusOK, page)
+}
+
+func (m *mockServer) serveRRSets(w http.ResponseWriter, r *http.Request, z *mockZone, rrtype, owner string) {
+	switch r.Method {
+	case "GET":
+		rrsets := []udnssdk.RRSet{}
+		for _, rrset := range z.find(rrtype, owner) {
+			if mockMatchesFilter(rrset, r.URL.Query().Get("q")) {
+				rrsets = append(rrsets, rrset)
+			}
+		}
+		if len(rrsets) == 0 {
+			mockError(w, r, http.StatusNotFound, 70002, "Data not found.")
+			return
+		}
+		mockRespond(w, r, http.StatusOK, udnssdk.RRSetListDTO{
+			ZoneName: z.properties.Name,
+			Rrsets:   rrsets,
+			Resultinfo: udnssdk.ResultInfo{
+				TotalCount:    len(rrsets),
+				ReturnedCount: len(rrsets),
+			},
+		})
+	case "POST", "PUT":
+		if rrtype == "ANY" || owner == "" {
+			mockError(w, r, http.StatusBadRequest, 0, "An RRType and owner name are required.")
+			return
+		}
+		var rrset udnssdk.RRSet
+		if err := json.NewDecoder(r.Body).Decode(&rrset); err != nil {
+			mockError(w, r, http.StatusBadRequest, 0, fmt.Sprintf("Malformed RRSet: %v", err))
+			return
+		}
+		exists := len(z.find(rrtype, owner)) > 0
+		if r.Method == "POST" && exists {
+			mockError(w, r, http.StatusBadRequest, 2111, fmt.Sprintf("Resource Record of type %s with these attributes already exists in the system.", rrtype))
+			return
+		}
+		if r.Method == "PUT" && !exists {
+			mockError(w, r, http.StatusNotFound, 70002, "Data not found.")
+			return
+		}
+		rrset.OwnerName, rrset.RRType = owner, rrtype
+		z.put(rrset)
+		z.bumpSerial()
+		status := http.StatusOK
+		if r.Method == "POST" {
+			status = http.StatusCreated
+		}
+		mockRespond(w, r, status, map[string]string{"message": "Successful"})
+	case "DELETE":
+		rrsets := z.find(rrtype, owner)
+		if owner == "" || len(rrsets) == 0 {
+			mockError(w, r, http.StatusNotFound, 70002, "Data not found.")
+			return
+		}
+		for _, rrset := range rrsets {
+			delete(z.rrsets, mockRRSetKey(rrset.RRType, rrset.OwnerName))
+		}
+		z.properties.ResourceRecordCount = len(z.rrsets)
+		z.bumpSerial()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		mockError(w, r, http.StatusMethodNotAllowed, 0, fmt.Sprintf("%s is not supported on RRSets", r.Method))
+	}
+}
+
+// mockMatchesFilter reports whether r matches the terms of a q
+// parameter, as rendered by rRSetQuery.Filter
+func mockMatchesFilter(r udnssdk.RRSet, q string) bool {
+	for _, term := range strings.Fields(q) {
+		kv := strings.SplitN(term, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch v := strings.ToLower(kv[1]); kv[0] {
+		case "owner":
+			if !strings.Contains(strings.ToLower(r.OwnerName), v) {
+				return false
+			}
+		case "ttl":
+			if strconv.Itoa(r.TTL) != v {
+				return false
+			}
+		case "value":
+			found := false
+			for _, rdata := range r.RData {
+				found = found || strings.Contains(strings.ToLower(rdata), v)
+			}
+			if !found {
+				return false
+			}
+		case "kind":
+			if (v == "records") != (r.Profile == nil) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mockRespond answers with v as JSON, gzip-compressed when the client
+// accepts it, as the API does
+func mockRespond(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.WriteHeader(status)
+	gz := gzip.NewWriter(w)
+	defer gz.Close()
+	json.NewEncoder(gz).Encode(v)
+}
+
+// mockError answers with the error list the API returns
+func mockError(w http.ResponseWriter, r *http.Request, status, code int, msg string) {
+	mockRespond(w, r, status, []map[string]interface{}{{"errorCode": code, "errorMessage": msg}})
+}
@@ -0,0 +1,145 @@
+package ultradns
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestMockServer(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+
+	r := rRSetResource{
+		OwnerName: "www",
+		RRType:    "A",
+		Zone:      "example.com",
+		TTL:       300,
+		RData:     []string{"192.0.2.1"},
+	}
+	if err := createRRSet(client, r, false); err != nil {
+		t.Fatalf("createRRSet: %v", err)
+	}
+	if err := createRRSet(client, r, false); err == nil {
+		t.Error("createRRSet of an existing RRSet: expected an error")
+	}
+	r.RData = []string{"192.0.2.2"}
+	if err := createRRSet(client, r, true); err != nil {
+		t.Fatalf("createRRSet with replaceExisting: %v", err)
+	}
+
+	rrsets, err := client.RRSets.Select(r.RRSetKey())
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if len(rrsets) != 1 || rrsets[0].OwnerName != "www.example.com." || rrsets[0].RRType != "A (1)" || rrsets[0].RData[0] != "192.0.2.2" {
+		t.Errorf("Select: got %+v", rrsets)
+	}
+
+	rrsets, err = selectRRSets(client, rRSetQuery{Zone: "example.com", Owner: "ww"})
+	if err != nil || len(rrsets) != 1 {
+		t.Errorf("selectRRSets by owner: got %+v, %v", rrsets, err)
+	}
+	rrsets, err = selectRRSets(client, rRSetQuery{Zone: "example.com", TTL: 300, Value: "192.0.2.2", Kind: "RECORDS"})
+	if err != nil || len(rrsets) != 1 {
+		t.Errorf("selectRRSets by ttl and value: got %+v, %v", rrsets, err)
+	}
+	if _, err = selectRRSets(client, rRSetQuery{Zone: "example.com", Value: "192.0.2.1"}); !isRRSetNotFound(err) {
+		t.Errorf("selectRRSets by a value no RRSet has: expected not found, got %v", err)
+	}
+
+	serial, err := findZoneSerial(client, "example.com")
+	if err != nil || serial != 3 {
+		t.Errorf("findZoneSerial: got %d, %v", serial, err)
+	}
+
+	z, err := findZone(client, "example.com")
+	if err != nil || z.Properties.ResourceRecordCount != 3 {
+		t.Errorf("findZone: got %+v, %v", z, err)
+	}
+
+	names := []string{}
+	err = selectZones(client, "", func(z zoneDTO) error {
+		names = append(names, z.Properties.Name)
+		return nil
+	})
+	if err != nil || len(names) != 1 || names[0] != "example.com." {
+		t.Errorf("selectZones: got %v, %v", names, err)
+	}
+
+	if _, err := client.RRSets.Delete(r.RRSetKey()); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := client.RRSets.Select(r.RRSetKey()); !isRRSetNotFound(err) {
+		t.Errorf("Select after Delete: expected not found, got %v", err)
+	}
+
+	for _, owner := range []string{"_dmarc", "_acme-challenge.www", "*", "*.dev", "0/25", "what?", "odd%name"} {
+		special := rRSetResource{OwnerName: owner, RRType: "TXT", Zone: "example.com", TTL: 300, RData: []string{"v=DMARC1; p=none"}}
+		if err := createRRSet(client, special, false); err != nil {
+			t.Fatalf("createRRSet(%q): %v", owner, err)
+		}
+		rrsets, err := client.RRSets.Select(special.RRSetKey())
+		if err != nil || len(rrsets) != 1 || rrsets[0].OwnerName != owner+".example.com." {
+			t.Errorf("Select(%q): got %+v, %v", owner, rrsets, err)
+		}
+		if _, err := client.RRSets.Delete(special.RRSetKey()); err != nil {
+			t.Errorf("Delete(%q): %v", owner, err)
+		}
+	}
+}
+
+func TestMockServer_stateFile(t *testing.T) {
+	for _, name := range []string{"ULTRADNS_USERNAME", "ULTRADNS_PASSWORD", "ULTRADNS_CREDENTIALS_FILE"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	dir, err := ioutil.TempDir("", "ultradns-mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mock.json")
+
+	// The mock needs no credentials
+	p := Provider().(*schema.Provider)
+	if err := p.Configure(terraform.NewResourceConfigRaw(map[string]interface{}{"mock": true, "mock_state_file": path})); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	r := rRSetResource{OwnerName: "www", RRType: "A", Zone: "example.com", TTL: 300, RData: []string{"192.0.2.1"}}
+	if err := createRRSet(p.Meta().(*Client), r, false); err != nil {
+		t.Fatalf("createRRSet: %v", err)
+	}
+
+	// A mock started from the file, as by the next Terraform command, has
+	// the record and the zone's serial
+	_, server, err := newMockServer(path)
+	if err != nil {
+		t.Fatalf("newMockServer: %v", err)
+	}
+	defer server.Close()
+	client, err := (&Config{BaseURL: server.URL}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	rrsets, err := client.RRSets.Select(r.RRSetKey())
+	if err != nil || len(rrsets) != 1 || rrsets[0].RData[0] != "192.0.2.1" {
+		t.Errorf("Select: got %+v, %v", rrsets, err)
+	}
+	if serial, err := findZoneSerial(client, "example.com"); err != nil || serial != 2 {
+		t.Errorf("findZoneSerial: got %d, %v", serial, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := newMockServer(path); err == nil {
+		t.Error("newMockServer of a malformed state file: expected an error")
+	}
+}
@@ -1,56 +1,534 @@
 package ultradns
 
 import (
-	"github.com/terra-farm/udnssdk"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/terra-farm/udnssdk"
 )
 
 // Provider returns a terraform.ResourceProvider.
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
+			// Required unless set by credentials_file, or a token is used
 			"username": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_USERNAME", nil),
 				Description: "UltraDNS Username.",
 			},
 
 			"password": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_PASSWORD", nil),
+				Sensitive:   true,
 				Description: "UltraDNS User Password",
 			},
+			"access_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_ACCESS_TOKEN", nil),
+				Sensitive:   true,
+				Description: "UltraDNS OAuth access token, to authenticate with instead of a password",
+			},
+			"refresh_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_REFRESH_TOKEN", nil),
+				Sensitive:   true,
+				Description: "UltraDNS OAuth refresh token, to obtain access tokens with instead of a password",
+			},
+			"credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_CREDENTIALS_FILE", nil),
+				Description: "INI or JSON file of profiles of username, password and base_url",
+			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_PROFILE", "default"),
+				Description: "Profile of credentials_file to use",
+			},
+			"base_url": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"ULTRADNS_BASE_URL", "ULTRADNS_BASEURL"}, nil),
+				ValidateFunc:  validation.IsURLWithHTTPorHTTPS,
+				ConflictsWith: []string{"baseurl"},
+				Description:   "UltraDNS Base URL, e.g. " + udnssdk.DefaultTestBaseURL + " for the customer test environment",
+			},
+			// A default here would override the environment of base_url
 			"baseurl": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_BASEURL", nil),
-				Default:     udnssdk.DefaultLiveBaseURL,
 				Description: "UltraDNS Base URL",
+				Deprecated:  "Use base_url instead",
+			},
+			"fallback_base_urls": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "UltraDNS Base URLs to use, in order, when base_url is unreachable as the provider is configured",
+			},
+			"token_cache_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_TOKEN_CACHE_FILE", nil),
+				Description: "File in which to keep access tokens between runs",
+			},
+			"change_comment": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_CHANGE_COMMENT", nil),
+				Description: "Comment recorded against every modifying API call, e.g. a CI build URL",
 			},
+			"audit_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_AUDIT_FILE", nil),
+				Description: "File to which a JSON line is appended for every create, update and delete API call",
+			},
+			"read_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_READ_ONLY", false),
+				Description: "Refuse to create, update or delete any resource",
+			},
+			"log_format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ULTRADNS_LOG_FORMAT", "text"),
+				ValidateFunc: validation.StringInSlice([]string{"text", "json"}, false),
+				Description:  "Format of the per-call API log lines: text or json",
+			},
+			"max_consecutive_failures": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ULTRADNS_MAX_CONSECUTIVE_FAILURES", 20),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of consecutive failed API calls after which all further calls fail at once; 0 disables",
+			},
+			"check_zone_serial": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_CHECK_ZONE_SERIAL", false),
+				Description: "Refuse to apply a change to a zone that was modified by something else since the change was planned",
+			},
+			"denied_record_types": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "RRTypes, e.g. NS, that plans may not create; creating one is an error at plan time",
+			},
+			"owner_name_policy": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Patterns that the names of the records written in a zone must match",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"zone": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"allow": {
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringIsValidRegExp,
+							},
+						},
+					},
+				},
+			},
+			"mock": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_MOCK", false),
+				Description: "Send API calls to an in-memory simulation of UltraDNS, for testing",
+			},
+			"mock_state_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ULTRADNS_MOCK_STATE_FILE", nil),
+				Description: "File in which to keep the zones of the mock API between runs",
+			},
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"ultradns_account":              dataSourceUltradnsAccount(),
+			"ultradns_caa_rdata":            dataSourceUltradnsCAARdata(),
+			"ultradns_dkim_txt":             dataSourceUltradnsDKIMTXT(),
+			"ultradns_dns_lookup":           dataSourceUltradnsDNSLookup(),
+			"ultradns_fqdn":                 dataSourceUltradnsFQDN(),
+			"ultradns_owner_rrtypes":        dataSourceUltradnsOwnerRRTypes(),
+			"ultradns_pool_health":          dataSourceUltradnsPoolHealth(),
+			"ultradns_rate_limit":           dataSourceUltradnsRateLimit(),
+			"ultradns_rdata_references":     dataSourceUltradnsRdataReferences(),
+			"ultradns_record_file":          dataSourceUltradnsRecordFile(),
+			"ultradns_record_types":         dataSourceUltradnsRecordTypes(),
+			"ultradns_records":              dataSourceUltradnsRecords(),
+			"ultradns_spf_flattened":        dataSourceUltradnsSPFFlattened(),
+			"ultradns_territories":          dataSourceUltradnsTerritories(),
+			"ultradns_tlsa_rdata":           dataSourceUltradnsTLSARdata(),
+			"ultradns_zone_transfer_status": dataSourceUltradnsZoneTransferStatus(),
+			"ultradns_zones":                dataSourceUltradnsZones(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"ultradns_dirpool":    resourceUltradnsDirpool(),
-			"ultradns_probe_http": resourceUltradnsProbeHTTP(),
-			"ultradns_probe_ping": resourceUltradnsProbePing(),
-			"ultradns_record":     resourceUltradnsRecord(),
-			"ultradns_tcpool":     resourceUltradnsTcpool(),
-			"ultradns_rdpool":     resourceUltradnsRdpool(),
+			"ultradns_dirpool":          resourceUltradnsDirpool(),
+			"ultradns_probe_http":       resourceUltradnsProbeHTTP(),
+			"ultradns_probe_ping":       resourceUltradnsProbePing(),
+			"ultradns_record":           resourceUltradnsRecord(),
+			"ultradns_record_set_group": resourceUltradnsRecordSetGroup(),
+			"ultradns_tcpool":           resourceUltradnsTcpool(),
+			"ultradns_rdpool":           resourceUltradnsRdpool(),
 		},
 
 		ConfigureFunc: providerConfigure,
 	}
+
+	for name, r := range p.ResourcesMap {
+		if zoneSerialResources[name] {
+			checkZoneSerials(r)
+			denyRecordTypes(name, r)
+			checkOwnerNamePolicy(name, r)
+		}
+		guardWrites(name, r)
+		annotateErrors(r)
+	}
+	for _, r := range p.DataSourcesMap {
+		annotateErrors(r)
+	}
+
+	return p
 }
 
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	config := Config{
-		Username: d.Get("username").(string),
-		Password: d.Get("password").(string),
-		BaseURL:  d.Get("baseurl").(string),
+		Username:        d.Get("username").(string),
+		Password:        d.Get("password").(string),
+		AccessToken:     d.Get("access_token").(string),
+		RefreshToken:    d.Get("refresh_token").(string),
+		BaseURL:         d.Get("base_url").(string),
+		ChangeComment:   d.Get("change_comment").(string),
+		ReadOnly:        d.Get("read_only").(bool),
+		LogFormat:       d.Get("log_format").(string),
+		Mock:            d.Get("mock").(bool),
+		MockStateFile:   d.Get("mock_state_file").(string),
+		CheckZoneSerial: d.Get("check_zone_serial").(bool),
+
+		MaxConsecutiveFailures: d.Get("max_consecutive_failures").(int),
+		FallbackBaseURLs:       stringsFromList(d.Get("fallback_base_urls").([]interface{})),
+		TokenCacheFile:         d.Get("token_cache_file").(string),
+		DeniedRecordTypes:      stringsFromList(d.Get("denied_record_types").([]interface{})),
+		OwnerNamePolicies:      map[string][]string{},
+		AuditFile:              d.Get("audit_file").(string),
+	}
+	if baseURL := d.Get("baseurl").(string); baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	if path := d.Get("credentials_file").(string); path != "" {
+		c, err := readCredentialsFile(path, d.Get("profile").(string))
+		if err != nil {
+			return nil, err
+		}
+		if config.Username == "" {
+			config.Username = c.Username
+		}
+		if config.Password == "" {
+			config.Password = c.Password
+		}
+		if config.BaseURL == "" {
+			config.BaseURL = c.BaseURL
+		}
+	}
+	// The mock API accepts any credentials, including none
+	if !config.Mock && config.AccessToken == "" && config.RefreshToken == "" && (config.Username == "" || config.Password == "") {
+		return nil, fmt.Errorf("username and password must be set, in the provider block, ULTRADNS_USERNAME and ULTRADNS_PASSWORD, or a credentials_file profile, " +
+			"unless access_token or refresh_token is")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = udnssdk.DefaultLiveBaseURL
+	}
+	for _, v := range d.Get("owner_name_policy").([]interface{}) {
+		m := v.(map[string]interface{})
+		zone := m["zone"].(string)
+		config.OwnerNamePolicies[zone] = append(config.OwnerNamePolicies[zone], stringsFromList(m["allow"].([]interface{}))...)
 	}
 
 	return config.Client()
 }
+
+// guardWrites wraps the Create, Update and Delete functions of the named
+// resource so that they fail before reaching the API when the provider
+// is configured with read_only
+func guardWrites(name string, r *schema.Resource) {
+	guard := func(op string, f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if f == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			if meta.(*Client).ReadOnly {
+				return fmt.Errorf("%s: refusing to %s %s: the provider is configured with read_only = true", name, op, describeWrite(r, d))
+			}
+			return f(d, meta)
+		}
+	}
+
+	r.Create = guard("create", r.Create)
+	r.Update = guard("update", r.Update)
+	r.Delete = guard("delete", r.Delete)
+}
+
+// describeWrite names the resource a refused write was for: its ID, or
+// before it is created, the zone, name and type it would have
+func describeWrite(r *schema.Resource, d *schema.ResourceData) string {
+	if d.Id() != "" {
+		return fmt.Sprintf("%q", d.Id())
+	}
+	parts := []string{}
+	for _, k := range []string{"zone", "name", "type"} {
+		if _, ok := r.Schema[k]; !ok {
+			continue
+		}
+		if v, _ := d.Get(k).(string); v != "" {
+			parts = append(parts, fmt.Sprintf("%s %q", k, v))
+		}
+	}
+	if len(parts) == 0 {
+		return "a new resource"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// zoneSerialResources are the resources that write RRSets, and so can
+// conflict with out-of-band edits of their zone
+var zoneSerialResources = map[string]bool{
+	"ultradns_dirpool":          true,
+	"ultradns_rdpool":           true,
+	"ultradns_record":           true,
+	"ultradns_record_set_group": true,
+	"ultradns_tcpool":           true,
+}
+
+// checkZoneSerials adds the computed zone_serial attribute to r. With
+// check_zone_serial, it is planned as the serial of the zone whenever r
+// changes, and Create and Update fail if the zone's serial has since been
+// changed by anything but this run's own writes.
+func checkZoneSerials(r *schema.Resource) {
+	r.Schema["zone_serial"] = &schema.Schema{
+		Type:     schema.TypeInt,
+		Computed: true,
+	}
+
+	customizeDiff := r.CustomizeDiff
+	r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+		if customizeDiff != nil {
+			if err := customizeDiff(d, meta); err != nil {
+				return err
+			}
+		}
+		client, ok := meta.(*Client)
+		zone := d.Get("zone").(string)
+		if !ok || !client.CheckZoneSerial || zone == "" || len(d.GetChangedKeysPrefix("")) == 0 {
+			return nil
+		}
+		serial, err := findZoneSerial(client, zone)
+		if err != nil {
+			return fmt.Errorf("zone serial check failed: %v", err)
+		}
+		return d.SetNew("zone_serial", serial)
+	}
+
+	check := func(f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		return func(d *schema.ResourceData, meta interface{}) error {
+			client := meta.(*Client)
+			zone := d.Get("zone").(string)
+			planned := d.Get("zone_serial").(int)
+			if !client.CheckZoneSerial || planned == 0 {
+				return f(d, meta)
+			}
+			if err := checkZoneSerial(client, zone, planned); err != nil {
+				return err
+			}
+			if err := f(d, meta); err != nil {
+				return err
+			}
+			rememberZoneSerial(client, zone)
+			return nil
+		}
+	}
+
+	r.Create = check(r.Create)
+	r.Update = check(r.Update)
+}
+
+// fixedRRTypes are the RRTypes of the resources that have no type
+// attribute
+var fixedRRTypes = map[string]string{
+	"ultradns_rdpool": "A",
+	"ultradns_tcpool": "A",
+}
+
+// denyRecordTypes wraps the CustomizeDiff of the named resource so that
+// a plan that creates an RRSet of a type in denied_record_types fails.
+// RRSets that already exist may still be updated and deleted.
+func denyRecordTypes(name string, r *schema.Resource) {
+	customizeDiff := r.CustomizeDiff
+	r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+		if customizeDiff != nil {
+			if err := customizeDiff(d, meta); err != nil {
+				return err
+			}
+		}
+		client, ok := meta.(*Client)
+		if !ok || len(client.DeniedRecordTypes) == 0 {
+			return nil
+		}
+		for _, typ := range createdRRTypes(name, d) {
+			if client.DeniedRecordTypes[strings.ToUpper(typ)] {
+				return fmt.Errorf("%s: creating %s records is not allowed: the provider is configured with denied_record_types = %v",
+					name, strings.ToUpper(typ), sortedKeys(client.DeniedRecordTypes))
+			}
+		}
+		return nil
+	}
+}
+
+// createdRRTypes returns the RRTypes of the RRSets the plan creates
+func createdRRTypes(name string, d *schema.ResourceDiff) []string {
+	if typ, ok := fixedRRTypes[name]; ok {
+		if d.Id() == "" {
+			return []string{typ}
+		}
+		return nil
+	}
+	if name == "ultradns_record_set_group" {
+		o, n := d.GetChange("record")
+		olds := recordSetGroupRecordsByKey(o.(*schema.Set))
+		types := []string{}
+		for k, m := range recordSetGroupRecordsByKey(n.(*schema.Set)) {
+			typ := m["type"].(string)
+			if old, ok := olds[k]; ok && strings.EqualFold(old["type"].(string), typ) {
+				continue
+			}
+			types = append(types, typ)
+		}
+		return types
+	}
+	types := []string{}
+	if d.Id() == "" || d.HasChange("type") {
+		types = append(types, d.Get("type").(string))
+	}
+	if name == "ultradns_record" && d.Get("create_ptr").(bool) && (d.Id() == "" || d.HasChange("create_ptr")) {
+		types = append(types, "PTR")
+	}
+	return types
+}
+
+// checkOwnerNamePolicy wraps the CustomizeDiff of the named resource so
+// that a plan that creates or changes an RRSet in a zone with an
+// owner_name_policy fails unless the RRSet's name matches one of the
+// zone's patterns. Deletes are not checked.
+func checkOwnerNamePolicy(name string, r *schema.Resource) {
+	customizeDiff := r.CustomizeDiff
+	r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+		if customizeDiff != nil {
+			if err := customizeDiff(d, meta); err != nil {
+				return err
+			}
+		}
+		client, ok := meta.(*Client)
+		zone := d.Get("zone").(string)
+		if !ok || zone == "" {
+			return nil
+		}
+		patterns, ok := client.OwnerNamePatterns[strings.ToLower(strings.TrimSuffix(zone, "."))]
+		if !ok {
+			return nil
+		}
+		for _, owner := range changedOwnerNames(name, d) {
+			if owner == "" {
+				// Not known until apply
+				continue
+			}
+			fqdn := strings.ToLower(strings.TrimSuffix(fqdnOwner(normalizeOwnerName(owner, zone), zone), "."))
+			if !matchesAny(patterns, fqdn) {
+				return fmt.Errorf("%s: %q is not an allowed name in zone %s: the provider's owner_name_policy only allows names matching %v",
+					name, fqdn, zone, patterns)
+			}
+		}
+		return nil
+	}
+}
+
+// changedOwnerNames returns the owner names of the RRSets the plan
+// creates or changes
+func changedOwnerNames(name string, d *schema.ResourceDiff) []string {
+	if name == "ultradns_record_set_group" {
+		o, n := d.GetChange("record")
+		olds := recordSetGroupRecordsByKey(o.(*schema.Set))
+		names := []string{}
+		for k, m := range recordSetGroupRecordsByKey(n.(*schema.Set)) {
+			if old, ok := olds[k]; ok && hashRecordSetGroupRecordContent(old) == hashRecordSetGroupRecordContent(m) {
+				continue
+			}
+			names = append(names, m["name"].(string))
+		}
+		return names
+	}
+	if d.Id() == "" || len(d.GetChangedKeysPrefix("")) > 0 {
+		return []string{d.Get("name").(string)}
+	}
+	return nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateErrors wraps the CRUD functions of r so that errors from a
+// failed API call carry the call's correlation and request IDs
+func annotateErrors(r *schema.Resource) {
+	annotate := func(f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if f == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			err := f(d, meta)
+			if c, ok := meta.(*Client); ok && c.transport != nil {
+				return c.transport.annotate(err)
+			}
+			return err
+		}
+	}
+
+	r.Create = annotate(r.Create)
+	r.Read = annotate(r.Read)
+	r.Update = annotate(r.Update)
+	r.Delete = annotate(r.Delete)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
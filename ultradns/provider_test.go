@@ -18,6 +18,12 @@ func init() {
 	}
 }
 
+func TestMain(m *testing.M) {
+	code := m.Run()
+	CloseMockServers()
+	os.Exit(code)
+}
+
 func TestProvider(t *testing.T) {
 	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
@@ -29,6 +35,15 @@ func TestProvider_impl(t *testing.T) {
 }
 
 func testAccPreCheck(t *testing.T) {
+	// The mock API accepts any credentials
+	if os.Getenv("ULTRADNS_MOCK") != "" {
+		for _, k := range []string{"ULTRADNS_USERNAME", "ULTRADNS_PASSWORD", "ULTRADNS_DOMAIN"} {
+			if os.Getenv(k) == "" {
+				os.Setenv(k, "mock")
+			}
+		}
+	}
+
 	if v := os.Getenv("ULTRADNS_USERNAME"); v == "" {
 		t.Fatal("ULTRADNS_USERNAME must be set for acceptance tests")
 	}
@@ -41,3 +56,153 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("ULTRADNS_DOMAIN must be set for acceptance tests. The domain is used to create and destroy record against.")
 	}
 }
+
+func TestProvider_readOnly(t *testing.T) {
+	p := Provider().(*schema.Provider)
+	meta := &Client{ReadOnly: true}
+
+	for name, r := range p.ResourcesMap {
+		d := r.TestResourceData()
+		if err := r.Create(d, meta); err == nil {
+			t.Errorf("%s: expected create to fail with read_only", name)
+		}
+		if r.Update != nil {
+			if err := r.Update(d, meta); err == nil {
+				t.Errorf("%s: expected update to fail with read_only", name)
+			}
+		}
+		if err := r.Delete(d, meta); err == nil {
+			t.Errorf("%s: expected delete to fail with read_only", name)
+		}
+	}
+
+	r := p.ResourcesMap["ultradns_record"]
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"zone": "example.com", "name": "www", "type": "A", "rdata": []interface{}{"192.0.2.1"},
+	})
+	err := r.Create(d, meta)
+	if want := `ultradns_record: refusing to create zone "example.com", name "www", type "A": the provider is configured with read_only = true`; err == nil || err.Error() != want {
+		t.Errorf("create: got %v, want %q", err, want)
+	}
+
+	// A resource without Update keeps none
+	noUpdate := &schema.Resource{Create: r.Create, Read: r.Read, Delete: r.Delete}
+	guardWrites("ultradns_test", noUpdate)
+	if noUpdate.Update != nil {
+		t.Error("guardWrites added an Update")
+	}
+}
+
+func TestProvider_deniedRecordTypes(t *testing.T) {
+	p := Provider().(*schema.Provider)
+	meta := &Client{DeniedRecordTypes: map[string]bool{"NS": true, "A": true}}
+
+	diff := func(name string, state *terraform.InstanceState, cfg map[string]interface{}) error {
+		_, err := p.ResourcesMap[name].Diff(state, terraform.NewResourceConfigRaw(cfg), meta)
+		return err
+	}
+	record := func(typ string) map[string]interface{} {
+		return map[string]interface{}{"zone": "example.com", "name": "test", "type": typ, "rdata": []interface{}{"ns1.example.net."}}
+	}
+
+	if err := diff("ultradns_record", nil, record("ns")); err == nil {
+		t.Errorf("ultradns_record: expected creating an NS record to fail")
+	}
+	if err := diff("ultradns_record", nil, record("CNAME")); err != nil {
+		t.Errorf("ultradns_record: creating a CNAME record: %v", err)
+	}
+	existing := &terraform.InstanceState{ID: "test:example.com", Attributes: map[string]string{
+		"zone": "example.com", "name": "test", "type": "NS", "ttl": "3600", "rdata.#": "1",
+	}}
+	if err := diff("ultradns_record", existing, record("NS")); err != nil {
+		t.Errorf("ultradns_record: updating an existing NS record: %v", err)
+	}
+
+	if err := diff("ultradns_rdpool", nil, map[string]interface{}{"zone": "example.com", "name": "test", "rdata": []interface{}{"192.0.2.1"}}); err == nil {
+		t.Errorf("ultradns_rdpool: expected creating an A pool to fail")
+	}
+
+	group := map[string]interface{}{"zone": "example.com", "record": []interface{}{
+		map[string]interface{}{"key": "ns", "name": "sub", "type": "NS", "rdata": []interface{}{"ns1.example.net."}},
+	}}
+	if err := diff("ultradns_record_set_group", nil, group); err == nil {
+		t.Errorf("ultradns_record_set_group: expected creating an NS record to fail")
+	}
+}
+
+func TestProvider_ownerNamePolicy(t *testing.T) {
+	p := Provider().(*schema.Provider)
+	patterns, err := compileOwnerNamePolicies(map[string][]string{"Example.com.": {`[^.]+\.svc\.example\.com`}})
+	if err != nil {
+		t.Fatalf("compileOwnerNamePolicies: %v", err)
+	}
+	meta := &Client{OwnerNamePatterns: patterns}
+
+	diff := func(name string, cfg map[string]interface{}) error {
+		_, err := p.ResourcesMap[name].Diff(nil, terraform.NewResourceConfigRaw(cfg), meta)
+		return err
+	}
+	record := func(zone, name string) map[string]interface{} {
+		return map[string]interface{}{"zone": zone, "name": name, "type": "A", "rdata": []interface{}{"192.0.2.1"}}
+	}
+
+	for _, name := range []string{"api.svc", "api.svc.example.com.", "API.svc.example.com"} {
+		if err := diff("ultradns_record", record("example.com", name)); err != nil {
+			t.Errorf("ultradns_record %q: %v", name, err)
+		}
+	}
+	for _, name := range []string{"@", "www", "a.b.svc", "api.svc.example.com.evil"} {
+		if err := diff("ultradns_record", record("example.com", name)); err == nil {
+			t.Errorf("ultradns_record %q: expected the owner_name_policy to refuse it", name)
+		}
+	}
+	if err := diff("ultradns_record", record("example.net", "www")); err != nil {
+		t.Errorf("ultradns_record in a zone without a policy: %v", err)
+	}
+	if err := diff("ultradns_tcpool", record("example.com", "www")); err == nil {
+		t.Errorf("ultradns_tcpool: expected the owner_name_policy to refuse www")
+	}
+
+	if _, err := compileOwnerNamePolicies(map[string][]string{"example.com": {"("}}); err == nil {
+		t.Errorf("expected an invalid pattern to fail")
+	}
+}
+
+func TestProvider_env(t *testing.T) {
+	for _, name := range []string{"ULTRADNS_USERNAME", "ULTRADNS_PASSWORD", "ULTRADNS_BASE_URL", "ULTRADNS_BASEURL"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	os.Setenv("ULTRADNS_USERNAME", "env-user")
+	os.Setenv("ULTRADNS_PASSWORD", "env-pass")
+
+	baseURL := func(cfg map[string]interface{}) string {
+		p := Provider().(*schema.Provider)
+		if err := p.Configure(terraform.NewResourceConfigRaw(cfg)); err != nil {
+			t.Fatalf("Configure(%v): %v", cfg, err)
+		}
+		client := p.Meta().(*Client)
+		if client.Config.Username != "env-user" || client.Config.Password != "env-pass" {
+			t.Errorf("credentials = %q, %q, want them from the environment", client.Config.Username, client.Config.Password)
+		}
+		return client.BaseURL.String()
+	}
+
+	if got := baseURL(map[string]interface{}{}); got != "https://restapi.ultradns.com/" {
+		t.Errorf("default base URL = %s", got)
+	}
+	os.Setenv("ULTRADNS_BASEURL", "https://old.example.net/")
+	if got := baseURL(map[string]interface{}{}); got != "https://old.example.net/" {
+		t.Errorf("ULTRADNS_BASEURL: base URL = %s", got)
+	}
+	os.Setenv("ULTRADNS_BASE_URL", "https://test-restapi.ultradns.com/")
+	if got := baseURL(map[string]interface{}{}); got != "https://test-restapi.ultradns.com/" {
+		t.Errorf("ULTRADNS_BASE_URL: base URL = %s", got)
+	}
+	if got := baseURL(map[string]interface{}{"baseurl": "https://config.example.net/"}); got != "https://config.example.net/" {
+		t.Errorf("baseurl: base URL = %s, want the configured one over the environment", got)
+	}
+	if got := baseURL(map[string]interface{}{"base_url": "https://config.example.net/"}); got != "https://config.example.net/" {
+		t.Errorf("base_url: base URL = %s, want the configured one over the environment", got)
+	}
+}
@@ -0,0 +1,193 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+// reverseName returns the in-addr.arpa or ip6.arpa name of ip
+func reverseName(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0])
+	}
+	const hex = "0123456789abcdef"
+	b := make([]byte, 0, 64)
+	for i := len(ip) - 1; i >= 0; i-- {
+		b = append(b, hex[ip[i]&0xf], '.', hex[ip[i]>>4], '.')
+	}
+	return string(b) + "ip6.arpa."
+}
+
+// findReverseZone returns the most specific zone of the account that
+// holds the reverse name, or "" if none does. Names one label below
+// in-addr.arpa or ip6.arpa are the least specific tried.
+func findReverseZone(client *Client, name string) (string, error) {
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	for i := 1; len(labels)-i >= 3; i++ {
+		zone := strings.Join(labels[i:], ".")
+		_, err := findZone(client, zone)
+		if err == nil {
+			return zone, nil
+		}
+		if !isZoneNotFound(err) {
+			return "", fmt.Errorf("looking up the reverse zone %s failed: %v", zone, err)
+		}
+	}
+	return "", nil
+}
+
+// ptrRecord is a reverse record managed along with an A or AAAA record.
+// Zone is empty when the account holds no reverse zone for IP.
+type ptrRecord struct {
+	IP   string
+	Zone string
+	Name string
+}
+
+func (p ptrRecord) RRSetKey() udnssdk.RRSetKey {
+	return udnssdk.RRSetKey{Zone: p.Zone, Type: "PTR", Name: escapeOwnerName(p.Name)}
+}
+
+func ptrRecordsFromList(l []interface{}) []ptrRecord {
+	ptrs := make([]ptrRecord, len(l))
+	for i, v := range l {
+		m := v.(map[string]interface{})
+		ptrs[i] = ptrRecord{IP: m["ip"].(string), Zone: m["zone"].(string), Name: m["name"].(string)}
+	}
+	return ptrs
+}
+
+// makePTRRecords encodes ptrs in the appropriate structure for the schema
+func makePTRRecords(ptrs []ptrRecord) []map[string]interface{} {
+	l := make([]map[string]interface{}, len(ptrs))
+	for i, p := range ptrs {
+		l[i] = map[string]interface{}{"ip": p.IP, "zone": p.Zone, "name": p.Name}
+	}
+	return l
+}
+
+// syncPTRRecords makes the reverse records of r match its addresses,
+// given the ones managed so far, and returns those now managed. On
+// error, every record that may still exist is returned with it; the
+// next read drops those that don't.
+func syncPTRRecords(client *Client, r rRSetResource, managed []ptrRecord) ([]ptrRecord, error) {
+	target := fqdnOwner(r.OwnerName, r.Zone)
+	byIP := map[string]ptrRecord{}
+	for _, p := range managed {
+		byIP[p.IP] = p
+	}
+
+	wanted := []ptrRecord{}
+	for _, rdata := range r.RData {
+		ip := net.ParseIP(rdata)
+		if ip == nil {
+			return managed, fmt.Errorf("create_ptr: %q is not an IP address", rdata)
+		}
+		name := reverseName(ip)
+		zone, err := findReverseZone(client, name)
+		if err != nil {
+			return managed, err
+		}
+		p := ptrRecord{IP: ip.String(), Zone: zone}
+		if zone == "" {
+			log.Printf("[WARN] ultradns_record %s: no reverse zone for %s in the account, not creating its PTR record", r.ID(), ip)
+		} else {
+			p.Name = normalizeOwnerName(name, zone)
+		}
+		wanted = append(wanted, p)
+	}
+
+	keep := map[udnssdk.RRSetKey]bool{}
+	for _, p := range wanted {
+		keep[p.RRSetKey()] = true
+	}
+	for _, p := range managed {
+		if p.Zone == "" || keep[p.RRSetKey()] {
+			continue
+		}
+		log.Printf("[INFO] ultradns_record %s delete PTR: %+v", r.ID(), p)
+		_, err := client.RRSets.Delete(p.RRSetKey())
+		if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
+			return managed, fmt.Errorf("create_ptr: delete of PTR %s.%s failed: %v", p.Name, p.Zone, err)
+		}
+	}
+
+	done := []ptrRecord{}
+	for _, p := range wanted {
+		if p.Zone == "" {
+			done = append(done, p)
+			continue
+		}
+		rrset := udnssdk.RRSet{OwnerName: p.Name, RRType: "PTR", TTL: r.TTL, RData: []string{target}}
+		existing, err := client.RRSets.Select(p.RRSetKey())
+		switch {
+		case isRRSetNotFound(err):
+			log.Printf("[INFO] ultradns_record %s create PTR: %+v", r.ID(), p)
+			_, err = client.RRSets.Create(p.RRSetKey(), rrset)
+		case err != nil:
+			// Reported below
+		case byIP[p.IP] == p || (len(existing[0].RData) == 1 && strings.EqualFold(existing[0].RData[0], target)):
+			log.Printf("[INFO] ultradns_record %s update PTR: %+v", r.ID(), p)
+			_, err = client.RRSets.Update(p.RRSetKey(), rrset)
+		default:
+			err = fmt.Errorf("it already exists, pointing at %v", existing[0].RData)
+		}
+		if err != nil {
+			return mergePTRRecords(done, managed), fmt.Errorf("create_ptr: writing PTR %s.%s failed: %v", p.Name, p.Zone, err)
+		}
+		done = append(done, p)
+	}
+	return done, nil
+}
+
+// mergePTRRecords returns a followed by the records of b not in it
+func mergePTRRecords(a, b []ptrRecord) []ptrRecord {
+	seen := map[ptrRecord]bool{}
+	for _, p := range a {
+		seen[p] = true
+	}
+	for _, p := range b {
+		if !seen[p] {
+			a = append(a, p)
+		}
+	}
+	return a
+}
+
+// deletePTRRecords deletes the reverse records managed along with r
+func deletePTRRecords(client *Client, r rRSetResource, managed []ptrRecord) error {
+	for _, p := range managed {
+		if p.Zone == "" {
+			continue
+		}
+		log.Printf("[INFO] ultradns_record %s delete PTR: %+v", r.ID(), p)
+		_, err := client.RRSets.Delete(p.RRSetKey())
+		if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
+			return fmt.Errorf("create_ptr: delete of PTR %s.%s failed: %v", p.Name, p.Zone, err)
+		}
+	}
+	return nil
+}
+
+// readPTRRecords returns the managed reverse records that still exist
+func readPTRRecords(client *Client, managed []ptrRecord) ([]ptrRecord, error) {
+	ptrs := []ptrRecord{}
+	for _, p := range managed {
+		if p.Zone != "" {
+			_, err := client.RRSets.Select(p.RRSetKey())
+			if isRRSetNotFound(err) || isZoneNotFound(err) {
+				log.Printf("[INFO] PTR %s.%s is gone, it will be recreated: %v", p.Name, p.Zone, err)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read of PTR %s.%s failed: %v", p.Name, p.Zone, err)
+			}
+		}
+		ptrs = append(ptrs, p)
+	}
+	return ptrs, nil
+}
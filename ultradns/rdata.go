@@ -0,0 +1,236 @@
+package ultradns
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// rdataCheckers check the rdata of the RRTypes whose presentation format
+// the provider knows, keyed by type name. Other types are sent as given
+// and left to the API to refuse.
+var rdataCheckers = map[string]func(string) error{
+	"CERT":  checkCERTRdata,
+	"DNAME": checkDNAMERdata,
+	"HINFO": checkHINFORdata,
+	"RP":    checkRPRdata,
+}
+
+// certTypes are the CERT certificate type mnemonics of RFC 4398 2.1
+var certTypes = map[string]bool{
+	"PKIX": true, "SPKI": true, "PGP": true, "IPKIX": true, "ISPKI": true,
+	"IPGP": true, "ACPKIX": true, "IACPKIX": true, "URI": true, "OID": true,
+}
+
+// checkRdata checks every value of rdata against the presentation format
+// of rrtype, if it has a checker
+func checkRdata(rrtype string, rdata []string) error {
+	check, ok := rdataCheckers[strings.ToUpper(rrtype)]
+	if !ok {
+		return nil
+	}
+	for _, v := range rdata {
+		if v == "" {
+			// Not known until apply
+			continue
+		}
+		if err := check(v); err != nil {
+			return fmt.Errorf("invalid %s rdata %q: %v", strings.ToUpper(rrtype), v, err)
+		}
+	}
+	return nil
+}
+
+// checkOverlappingRdata refuses two values of rdata that UltraDNS would
+// store as one, e.g. "2001:db8::1" and "2001:DB8:0::1", or "192.0.2.1"
+// and "192.0.2.1 ", as the record would then never match its
+// configuration. Values written identically can't be found: Terraform
+// merges them into one element of the rdata set before the provider
+// sees the configuration.
+func checkOverlappingRdata(rrtype string, rdata []string) error {
+	seen := map[string]string{}
+	for _, v := range rdata {
+		if v == "" {
+			// Not known until apply
+			continue
+		}
+		c := canonicalRdata(rrtype, v)
+		if other, ok := seen[c]; ok {
+			return fmt.Errorf("%s rdata %q and %q are the same value; remove one of them", strings.ToUpper(rrtype), other, v)
+		}
+		seen[c] = v
+	}
+	return nil
+}
+
+// validateRdataValue is a SchemaValidateFunc for one rdata value. It
+// warns about values with a stray space or leading zero, which hash
+// apart from the value they were copied from in the rdata set, so that
+// Terraform keeps both.
+func validateRdataValue(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if trimmed := strings.TrimSpace(value); trimmed != value {
+		ws = append(ws, fmt.Sprintf("%s: rdata %q has leading or trailing spaces, which make it a different value from %q", k, value, trimmed))
+		value = trimmed
+	}
+	if ip := parseIPv4LeadingZeros(value); ip != nil {
+		ws = append(ws, fmt.Sprintf("%s: rdata %q is the address %s written with leading zeros", k, value, ip))
+	}
+	return
+}
+
+// parseIPv4LeadingZeros parses a dotted-quad address with leading zeros
+// in a part, such as "01.2.3.4", which net.ParseIP refuses. It returns
+// nil for any other value.
+func parseIPv4LeadingZeros(v string) net.IP {
+	parts := strings.Split(v, ".")
+	if len(parts) != 4 {
+		return nil
+	}
+	zeros := false
+	ip := make(net.IP, 4)
+	for i, p := range parts {
+		if len(p) == 0 || len(p) > 3 || !isUintBelow(p, 256) {
+			return nil
+		}
+		zeros = zeros || (len(p) > 1 && p[0] == '0')
+		n, _ := strconv.Atoi(p)
+		ip[i] = byte(n)
+	}
+	if !zeros {
+		return nil
+	}
+	return ip
+}
+
+// canonicalRdata returns the form of v that differently written values
+// of rrtype that mean the same have in common
+func canonicalRdata(rrtype, v string) string {
+	switch strings.ToUpper(rrtype) {
+	case "A", "AAAA":
+		v = strings.TrimSpace(v)
+		if ip := net.ParseIP(v); ip != nil {
+			return ip.String()
+		}
+		if ip := parseIPv4LeadingZeros(v); ip != nil {
+			return ip.String()
+		}
+	case "TXT", "SPF":
+		return normalizeTXTRdata(v)
+	case "CNAME", "DNAME", "NS", "PTR", "MX", "SRV":
+		// Names are case-insensitive, and absolute with or without the dot
+		fields := strings.Fields(strings.ToLower(v))
+		if len(fields) > 0 {
+			fields[len(fields)-1] = strings.TrimSuffix(fields[len(fields)-1], ".")
+		}
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// checkCERTRdata checks "type key-tag algorithm certificate", RFC 4398 2.2
+func checkCERTRdata(rdata string) error {
+	fields := strings.Fields(rdata)
+	if len(fields) < 4 {
+		return fmt.Errorf("want type, key tag, algorithm and certificate")
+	}
+	if !certTypes[strings.ToUpper(fields[0])] && !isUintBelow(fields[0], 1<<16) {
+		return fmt.Errorf("certificate type %q is neither a mnemonic nor 0-65535", fields[0])
+	}
+	if !isUintBelow(fields[1], 1<<16) {
+		return fmt.Errorf("key tag %q is not 0-65535", fields[1])
+	}
+	// Algorithm mnemonics are those of DNSSEC, e.g. RSASHA256
+	if !isUintBelow(fields[2], 1<<8) && !isMnemonic(fields[2]) {
+		return fmt.Errorf("algorithm %q is neither a mnemonic nor 0-255", fields[2])
+	}
+	// The certificate may be split over several fields
+	if _, err := base64.StdEncoding.DecodeString(strings.Join(fields[3:], "")); err != nil {
+		return fmt.Errorf("certificate is not base64: %v", err)
+	}
+	return nil
+}
+
+// checkDNAMERdata checks the single target name of a DNAME, RFC 6672
+func checkDNAMERdata(rdata string) error {
+	fields := strings.Fields(rdata)
+	if len(fields) != 1 {
+		return fmt.Errorf("want a single target name")
+	}
+	return checkDomainName(fields[0])
+}
+
+// checkHINFORdata checks the CPU and OS character-strings of HINFO,
+// RFC 1035 3.3.2. Strings without spaces may be left unquoted.
+func checkHINFORdata(rdata string) error {
+	ss := strings.Fields(rdata)
+	if strings.Contains(rdata, `"`) {
+		ss = splitTXTStrings(rdata)
+	}
+	if len(ss) != 2 {
+		return fmt.Errorf("want a CPU and an OS string, quoted if they contain spaces")
+	}
+	for _, s := range ss {
+		if len(s) > 255 {
+			return fmt.Errorf("%q is longer than 255 characters", s)
+		}
+		if strings.Contains(s, `"`) {
+			return fmt.Errorf("%q is not a quoted string", s)
+		}
+	}
+	return nil
+}
+
+// checkRPRdata checks the mailbox and TXT names of RP, RFC 1183 2.2.
+// Either may be "." when there is none.
+func checkRPRdata(rdata string) error {
+	fields := strings.Fields(rdata)
+	if len(fields) != 2 {
+		return fmt.Errorf("want a mailbox name and a TXT name")
+	}
+	for _, name := range fields {
+		if err := checkDomainName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDomainName checks the length limits of RFC 1035 2.3.4 on a name
+// in presentation format. "." is the root.
+func checkDomainName(name string) error {
+	if name == "." {
+		return nil
+	}
+	if len(strings.TrimSuffix(name, ".")) > 253 {
+		return fmt.Errorf("name %q is longer than 253 characters", name)
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			return fmt.Errorf("name %q has an empty label", name)
+		}
+		if len(label) > 63 {
+			return fmt.Errorf("name %q has a label longer than 63 characters", name)
+		}
+	}
+	return nil
+}
+
+func isUintBelow(s string, limit uint64) bool {
+	n, err := strconv.ParseUint(s, 10, 64)
+	return err == nil && n < limit
+}
+
+// isMnemonic reports whether s is a letter followed by letters, digits
+// and hyphens
+func isMnemonic(s string) bool {
+	for i, c := range s {
+		letter := c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+		if !letter && (i == 0 || !(c >= '0' && c <= '9' || c == '-')) {
+			return false
+		}
+	}
+	return s != ""
+}
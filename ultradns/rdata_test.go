@@ -0,0 +1,84 @@
+package ultradns
+
+import "testing"
+
+func TestCheckRdata(t *testing.T) {
+	cases := []struct {
+		rrtype string
+		rdata  string
+		err    bool
+	}{
+		{"CERT", "PKIX 12345 RSASHA256 MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA", false},
+		{"cert", "1 0 8 TUlJQklq TkJna3Fo", false},
+		{"CERT", "PKIX 12345 8", true},
+		{"CERT", "BOGUS 12345 8 TUlJQklq", true},
+		{"CERT", "PKIX 65536 8 TUlJQklq", true},
+		{"CERT", "PKIX 1 256 TUlJQklq", true},
+		{"CERT", "PKIX 1 8 not*base64", true},
+		{"DNAME", "example.net.", false},
+		{"DNAME", "example.net. extra", true},
+		{"DNAME", "a..example.net.", true},
+		{"HINFO", `"INTEL-386" "Windows NT"`, false},
+		{"HINFO", "INTEL-386 Linux", false},
+		{"HINFO", "INTEL-386", true},
+		{"HINFO", `"INTEL-386" "Windows" "NT"`, true},
+		{"RP", "admin.example.com. info.example.com.", false},
+		{"RP", "admin.example.com. .", false},
+		{"RP", "admin.example.com.", true},
+		{"A", "anything goes", false},
+		{"DNAME", "", false},
+	}
+
+	for _, c := range cases {
+		err := checkRdata(c.rrtype, []string{c.rdata})
+		if (err != nil) != c.err {
+			t.Errorf("checkRdata(%q, %q) = %v, want error: %v", c.rrtype, c.rdata, err, c.err)
+		}
+	}
+}
+
+func TestValidateRdataValue(t *testing.T) {
+	for v, warn := range map[string]bool{
+		"1.2.3.4":     false,
+		"1.2.3.4 ":    true,
+		" 1.2.3.4":    true,
+		"01.2.3.4":    true,
+		"1.2.3.004":   true,
+		"0.0.0.0":     false,
+		"v=spf1 -all": false,
+		"256.01.0.1":  false,
+	} {
+		ws, es := validateRdataValue(v, "rdata")
+		if (len(ws) != 0) != warn || len(es) != 0 {
+			t.Errorf("validateRdataValue(%q): warnings %v, errors %v, want warnings: %v", v, ws, es, warn)
+		}
+	}
+}
+
+func TestCheckOverlappingRdata(t *testing.T) {
+	cases := []struct {
+		rrtype string
+		rdata  []string
+		err    bool
+	}{
+		{"A", []string{"192.0.2.1", "192.0.2.2"}, false},
+		{"AAAA", []string{"2001:db8::1", "2001:DB8:0::1"}, true},
+		{"A", []string{"192.0.2.1", "::ffff:192.0.2.1"}, true},
+		{"NS", []string{"ns1.example.net.", "NS1.example.net"}, true},
+		{"MX", []string{"10 mx.example.com.", "10  mx.example.com."}, true},
+		{"MX", []string{"10 mx.example.com.", "20 mx.example.com."}, false},
+		{"TXT", []string{"Token=abc", "token=abc"}, false},
+		{"CAA", []string{`0 issue "ca.example.net"`, `0 issue  "ca.example.net"`}, true},
+		{"A", []string{"", ""}, false},
+		{"A", []string{"1.2.3.4", "1.2.3.4 "}, true},
+		{"A", []string{"1.2.3.4", "01.2.3.4"}, true},
+		{"A", []string{"1.2.3.4", "01.2.3.5"}, false},
+	}
+
+	for _, c := range cases {
+		err := checkOverlappingRdata(c.rrtype, c.rdata)
+		if (err != nil) != c.err {
+			t.Errorf("checkOverlappingRdata(%q, %q) = %v, want error: %v", c.rrtype, c.rdata, err, c.err)
+		}
+	}
+}
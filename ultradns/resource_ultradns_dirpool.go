@@ -7,11 +7,12 @@ import (
 	"log"
 	"strings"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/fatih/structs"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/mitchellh/mapstructure"
+	"github.com/terra-farm/udnssdk"
 )
 
 func resourceUltradnsDirpool() *schema.Resource {
@@ -21,6 +22,8 @@ func resourceUltradnsDirpool() *schema.Resource {
 		Update: resourceUltradnsDirpoolUpdate,
 		Delete: resourceUltradnsDirpoolDelete,
 
+		CustomizeDiff: resourceUltradnsDirpoolCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			// Required
 			"zone": {
@@ -29,9 +32,10 @@ func resourceUltradnsDirpool() *schema.Resource {
 				ForceNew: true,
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressOwnerNameDiff,
 			},
 			"type": {
 				Type:     schema.TypeString,
@@ -142,9 +146,10 @@ func resourceUltradnsDirpool() *schema.Resource {
 			},
 			// Optional
 			"ttl": {
-				Type:     schema.TypeInt,
-				Optional: true,
-				Default:  3600,
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntBetween(0, maxTTL),
 			},
 			"conflict_resolve": {
 				Type:     schema.TypeString,
@@ -241,6 +246,11 @@ func resourceUltradnsDirpool() *schema.Resource {
 					},
 				},
 			},
+			"replace_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 			// Computed
 			"hostname": {
 				Type:     schema.TypeString,
@@ -253,7 +263,7 @@ func resourceUltradnsDirpool() *schema.Resource {
 // CRUD Operations
 
 func resourceUltradnsDirpoolCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeDirpoolRRSetResource(d)
 	if err != nil {
@@ -261,7 +271,7 @@ func resourceUltradnsDirpoolCreate(d *schema.ResourceData, meta interface{}) err
 	}
 
 	log.Printf("[INFO] ultradns_dirpool create: %#v", r)
-	_, err = client.RRSets.Create(r.RRSetKey(), r.RRSet())
+	err = createRRSet(client, r, d.Get("replace_existing").(bool))
 	if err != nil {
 		// FIXME: remove the json from log
 		marshalled, _ := json.Marshal(r)
@@ -276,7 +286,7 @@ func resourceUltradnsDirpoolCreate(d *schema.ResourceData, meta interface{}) err
 }
 
 func resourceUltradnsDirpoolRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	rr, err := makeDirpoolRRSetResource(d)
 	if err != nil {
@@ -285,16 +295,11 @@ func resourceUltradnsDirpoolRead(d *schema.ResourceData, meta interface{}) error
 
 	rrsets, err := client.RRSets.Select(rr.RRSetKey())
 	if err != nil {
-		uderr, ok := err.(*udnssdk.ErrorResponseList)
-		if ok {
-			for _, resps := range uderr.Responses {
-				// 70002 means Records Not Found
-				if resps.ErrorCode == 70002 {
-					d.SetId("")
-					return nil
-				}
-				return fmt.Errorf("resource not found: %v", err)
-			}
+		// Deleted outside Terraform, possibly along with its whole zone
+		if isRRSetNotFound(err) || isZoneNotFound(err) {
+			log.Printf("[INFO] ultradns_dirpool %s is gone, removing it from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
 		}
 		return fmt.Errorf("resource not found: %v", err)
 	}
@@ -305,7 +310,7 @@ func resourceUltradnsDirpoolRead(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceUltradnsDirpoolUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeDirpoolRRSetResource(d)
 	if err != nil {
@@ -322,7 +327,7 @@ func resourceUltradnsDirpoolUpdate(d *schema.ResourceData, meta interface{}) err
 }
 
 func resourceUltradnsDirpoolDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeDirpoolRRSetResource(d)
 	if err != nil {
@@ -331,15 +336,75 @@ func resourceUltradnsDirpoolDelete(d *schema.ResourceData, meta interface{}) err
 
 	log.Printf("[INFO] ultradns_dirpool delete: %+v", r)
 	_, err = client.RRSets.Delete(r.RRSetKey())
-	if err != nil {
+	if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
 		return fmt.Errorf("resource delete failed: %v", err)
 	}
 
 	return nil
 }
 
+func resourceUltradnsDirpoolCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("zone") || !d.NewValueKnown("rdata") || !d.NewValueKnown("no_response") {
+		return nil
+	}
+
+	infos, err := makeDirpoolRdataInfos(d.Get("rdata").(*schema.Set).List())
+	if err != nil {
+		return err
+	}
+	for _, nr := range d.Get("no_response").([]interface{}) {
+		ri, err := makeDirpoolRdataInfo(nr)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, ri)
+	}
+	return checkDirpoolAccountLevelGroups(meta.(*Client), d.Get("zone").(string), infos)
+}
+
 // Resource Helpers
 
+// checkDirpoolAccountLevelGroups checks that every account-level geo and
+// IP group referenced by infos exists in the account that owns zone.
+// Only the group name is sent to the API, which resolves the definition
+// itself, so edits to a group apply to every pool that references it.
+func checkDirpoolAccountLevelGroups(client *Client, zone string, infos []udnssdk.DPRDataInfo) error {
+	geos, ips := []string{}, []string{}
+	for _, ri := range infos {
+		if ri.GeoInfo != nil && ri.GeoInfo.IsAccountLevel {
+			geos = append(geos, ri.GeoInfo.Name)
+		}
+		if ri.IPInfo != nil && ri.IPInfo.IsAccountLevel {
+			ips = append(ips, ri.IPInfo.Name)
+		}
+	}
+	if len(geos) == 0 && len(ips) == 0 {
+		return nil
+	}
+
+	z, err := findZone(client, zone)
+	if err != nil {
+		return fmt.Errorf("zone %q lookup failed: %v", zone, err)
+	}
+	account := udnssdk.AccountKey(z.Properties.AccountName)
+
+	for _, name := range geos {
+		k := udnssdk.GeoDirectionalPoolKey{Account: account, Name: name}
+		_, _, err := client.DirectionalPools.Geos().Find(k)
+		if err != nil {
+			return fmt.Errorf("account-level geo group %q not found in account %q: %v", name, account, err)
+		}
+	}
+	for _, name := range ips {
+		k := udnssdk.IPDirectionalPoolKey{Account: account, Name: name}
+		_, _, err := client.DirectionalPools.IPs().Find(k)
+		if err != nil {
+			return fmt.Errorf("account-level IP group %q not found in account %q: %v", name, account, err)
+		}
+	}
+	return nil
+}
+
 // makeDirpoolRRSetResource converts ResourceData into an rRSetResource
 // ready for use in any CRUD operation
 func makeDirpoolRRSetResource(d *schema.ResourceData) (rRSetResource, error) {
@@ -347,7 +412,7 @@ func makeDirpoolRRSetResource(d *schema.ResourceData) (rRSetResource, error) {
 	res := rRSetResource{
 		RRType:    d.Get("type").(string),
 		Zone:      d.Get("zone").(string),
-		OwnerName: d.Get("name").(string),
+		OwnerName: normalizeOwnerName(d.Get("name").(string), d.Get("zone").(string)),
 		TTL:       d.Get("ttl").(int),
 		RData:     unzipRdataHosts(rDataRaw),
 	}
@@ -484,6 +549,12 @@ func makeGeoInfo(configured interface{}) (udnssdk.GeoInfo, error) {
 	}
 
 	rawCodes := c["codes"].(*schema.Set).List()
+	if res.IsAccountLevel {
+		if res.Name == "" || len(rawCodes) > 0 {
+			return res, fmt.Errorf("is_account_level requires a name and no codes; the codes come from the account-level group")
+		}
+		return res, nil
+	}
 	res.Codes = make([]string, 0, len(rawCodes))
 	for _, i := range rawCodes {
 		res.Codes = append(res.Codes, i.(string))
@@ -502,6 +573,12 @@ func makeIPInfo(configured interface{}) (udnssdk.IPInfo, error) {
 	}
 
 	rawIps := c["ips"].(*schema.Set).List()
+	if res.IsAccountLevel {
+		if res.Name == "" || len(rawIps) > 0 {
+			return res, fmt.Errorf("is_account_level requires a name and no ips; the ips come from the account-level group")
+		}
+		return res, nil
+	}
 	res.Ips = make([]udnssdk.IPAddrDTO, 0, len(rawIps))
 	for _, rawIa := range rawIps {
 		var i udnssdk.IPAddrDTO
@@ -550,6 +627,10 @@ func mapFromIPInfos(rdi *udnssdk.IPInfo) []map[string]interface{} {
 			"is_account_level": rdi.IsAccountLevel,
 			"ips":              makeSetFromIPAddrDTOs(rdi.Ips),
 		}
+		// Account-level groups are referenced by name only
+		if rdi.IsAccountLevel {
+			m["ips"] = makeSetFromIPAddrDTOs(nil)
+		}
 		res = append(res, m)
 	}
 	return res
@@ -572,6 +653,10 @@ func mapFromGeoInfos(gi *udnssdk.GeoInfo) []map[string]interface{} {
 	if gi != nil {
 		m := mapEncode(gi)
 		m["codes"] = makeSetFromStrings(gi.Codes)
+		// Account-level groups are referenced by name only
+		if gi.IsAccountLevel {
+			m["codes"] = makeSetFromStrings(nil)
+		}
 		res = append(res, m)
 	}
 	return res
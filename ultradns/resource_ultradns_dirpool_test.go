@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/terra-farm/udnssdk"
 )
 
 func TestAccUltradnsDirpool(t *testing.T) {
@@ -69,7 +70,7 @@ func TestAccUltradnsDirpool(t *testing.T) {
 }
 
 func testAccDirpoolCheckDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*udnssdk.Client)
+	client := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "ultradns_dirpool" {
@@ -190,3 +191,24 @@ resource "ultradns_dirpool" "it" {
   }
 }
 `
+
+func TestMakeGeoInfo_accountLevel(t *testing.T) {
+	codes := func(cs ...string) *schema.Set { return makeSetFromStrings(cs) }
+
+	gi, err := makeGeoInfo(map[string]interface{}{"name": "accountGeoGroup", "is_account_level": true, "codes": codes()})
+	if err != nil {
+		t.Fatalf("account-level reference: unexpected error %v", err)
+	}
+	if gi.Name != "accountGeoGroup" || !gi.IsAccountLevel || len(gi.Codes) != 0 {
+		t.Errorf("account-level reference: got %#v", gi)
+	}
+
+	_, err = makeGeoInfo(map[string]interface{}{"name": "accountGeoGroup", "is_account_level": true, "codes": codes("Z4")})
+	if err == nil {
+		t.Errorf("account-level reference with codes: expected error")
+	}
+	_, err = makeGeoInfo(map[string]interface{}{"name": "", "is_account_level": true, "codes": codes()})
+	if err == nil {
+		t.Errorf("account-level reference without name: expected error")
+	}
+}
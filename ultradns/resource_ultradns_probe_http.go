@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/terra-farm/udnssdk"
 )
 
 func resourceUltradnsProbeHTTP() *schema.Resource {
@@ -15,6 +15,8 @@ func resourceUltradnsProbeHTTP() *schema.Resource {
 		Update: resourceUltradnsProbeHTTPUpdate,
 		Delete: resourceUltradnsProbeHTTPDelete,
 
+		CustomizeDiff: resourceUltradnsProbeHTTPCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			// Key
 			"zone": {
@@ -92,7 +94,7 @@ func schemaHTTPProbe() *schema.Resource {
 							Type:     schema.TypeSet,
 							Optional: true,
 							Set:      hashLimits,
-							Elem:     resourceProbeLimits(),
+							Elem:     resourceProbeLimits(httpProbeLimitNames),
 						},
 					},
 				},
@@ -122,7 +124,7 @@ func schemaHTTPProbe() *schema.Resource {
 }
 
 func resourceUltradnsProbeHTTPCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeHTTPProbeResource(d)
 	if err != nil {
@@ -144,7 +146,7 @@ func resourceUltradnsProbeHTTPCreate(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceUltradnsProbeHTTPRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeHTTPProbeResource(d)
 	if err != nil {
@@ -156,16 +158,11 @@ func resourceUltradnsProbeHTTPRead(d *schema.ResourceData, meta interface{}) err
 	log.Printf("[DEBUG] ultradns_probe_http response: %#v", probe)
 
 	if err != nil {
-		uderr, ok := err.(*udnssdk.ErrorResponseList)
-		if ok {
-			for _, r := range uderr.Responses {
-				// 70002 means Probes Not Found
-				if r.ErrorCode == 70002 {
-					d.SetId("")
-					return nil
-				}
-				return fmt.Errorf("not found: %s", err)
-			}
+		// Deleted outside Terraform, possibly along with its whole zone
+		if isRRSetNotFound(err) || isZoneNotFound(err) {
+			log.Printf("[INFO] ultradns_probe_http %s is gone, removing it from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
 		}
 		return fmt.Errorf("not found: %s", err)
 	}
@@ -174,7 +171,7 @@ func resourceUltradnsProbeHTTPRead(d *schema.ResourceData, meta interface{}) err
 }
 
 func resourceUltradnsProbeHTTPUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeHTTPProbeResource(d)
 	if err != nil {
@@ -191,7 +188,7 @@ func resourceUltradnsProbeHTTPUpdate(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceUltradnsProbeHTTPDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makeHTTPProbeResource(d)
 	if err != nil {
@@ -200,13 +197,37 @@ func resourceUltradnsProbeHTTPDelete(d *schema.ResourceData, meta interface{}) e
 
 	log.Printf("[INFO] ultradns_probe_http delete: %+v", r)
 	_, err = client.Probes.Delete(r.Key())
-	if err != nil {
+	if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
 		return fmt.Errorf("delete failed: %s", err)
 	}
 
 	return nil
 }
 
+func resourceUltradnsProbeHTTPCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	for i, rhp := range d.Get("http_probe").([]interface{}) {
+		hp, ok := rhp.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for j, rt := range hp["transaction"].([]interface{}) {
+			t, ok := rt.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			k := fmt.Sprintf("http_probe.%d.transaction.%d.limit", i, j)
+			if err := checkProbeLimits(k, t["limit"].(*schema.Set).List()); err != nil {
+				return err
+			}
+		}
+		k := fmt.Sprintf("http_probe.%d.total_limits", i)
+		if err := checkProbeLimits(k, hp["total_limits"].([]interface{})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Resource Helpers
 
 func makeHTTPProbeResource(d *schema.ResourceData) (probeResource, error) {
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/terra-farm/udnssdk"
 )
 
 func resourceUltradnsProbePing() *schema.Resource {
@@ -15,6 +15,8 @@ func resourceUltradnsProbePing() *schema.Resource {
 		Update: resourceUltradnsProbePingUpdate,
 		Delete: resourceUltradnsProbePingDelete,
 
+		CustomizeDiff: resourceUltradnsProbePingCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			// Key
 			"zone": {
@@ -63,7 +65,7 @@ func resourceUltradnsProbePing() *schema.Resource {
 }
 
 func resourceUltradnsProbePingCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makePingProbeResource(d)
 	if err != nil {
@@ -85,7 +87,7 @@ func resourceUltradnsProbePingCreate(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceUltradnsProbePingRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makePingProbeResource(d)
 	if err != nil {
@@ -97,16 +99,11 @@ func resourceUltradnsProbePingRead(d *schema.ResourceData, meta interface{}) err
 	log.Printf("[DEBUG] ultradns_probe_ping response: %#v", probe)
 
 	if err != nil {
-		uderr, ok := err.(*udnssdk.ErrorResponseList)
-		if ok {
-			for _, r := range uderr.Responses {
-				// 70002 means Probes Not Found
-				if r.ErrorCode == 70002 {
-					d.SetId("")
-					return nil
-				}
-				return fmt.Errorf("not found: %s", err)
-			}
+		// Deleted outside Terraform, possibly along with its whole zone
+		if isRRSetNotFound(err) || isZoneNotFound(err) {
+			log.Printf("[INFO] ultradns_probe_ping %s is gone, removing it from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
 		}
 		return fmt.Errorf("not found: %s", err)
 	}
@@ -115,7 +112,7 @@ func resourceUltradnsProbePingRead(d *schema.ResourceData, meta interface{}) err
 }
 
 func resourceUltradnsProbePingUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makePingProbeResource(d)
 	if err != nil {
@@ -132,7 +129,7 @@ func resourceUltradnsProbePingUpdate(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceUltradnsProbePingDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := makePingProbeResource(d)
 	if err != nil {
@@ -141,13 +138,27 @@ func resourceUltradnsProbePingDelete(d *schema.ResourceData, meta interface{}) e
 
 	log.Printf("[INFO] ultradns_probe_ping delete: %+v", r)
 	_, err = client.Probes.Delete(r.Key())
-	if err != nil {
+	if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
 		return fmt.Errorf("delete failed: %s", err)
 	}
 
 	return nil
 }
 
+func resourceUltradnsProbePingCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	for i, rpp := range d.Get("ping_probe").([]interface{}) {
+		pp, ok := rpp.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		k := fmt.Sprintf("ping_probe.%d.limit", i)
+		if err := checkProbeLimits(k, pp["limit"].(*schema.Set).List()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Resource Helpers
 
 func makePingProbeResource(d *schema.ResourceData) (probeResource, error) {
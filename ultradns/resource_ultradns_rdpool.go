@@ -5,9 +5,9 @@ import (
 	"log"
 	"strings"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
 )
 
 func resourceUltradnsRdpool() *schema.Resource {
@@ -25,13 +25,15 @@ func resourceUltradnsRdpool() *schema.Resource {
 				ForceNew: true,
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressOwnerNameDiff,
 			},
+			// rdata is a list rather than a set because its order is
+			// meaningful when order is FIXED
 			"rdata": {
-				Type:     schema.TypeSet,
-				Set:      schema.HashString,
+				Type:     schema.TypeList,
 				Required: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
@@ -44,7 +46,10 @@ func resourceUltradnsRdpool() *schema.Resource {
 					"ROUND_ROBIN",
 					"FIXED",
 					"RANDOM",
-				}, false),
+				}, true),
+				StateFunc: func(v interface{}) string {
+					return strings.ToUpper(v.(string))
+				},
 			},
 			"description": {
 				Type:         schema.TypeString,
@@ -52,9 +57,15 @@ func resourceUltradnsRdpool() *schema.Resource {
 				ValidateFunc: validation.StringLenBetween(0, 255),
 			},
 			"ttl": {
-				Type:     schema.TypeInt,
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntBetween(0, maxTTL),
+			},
+			"replace_existing": {
+				Type:     schema.TypeBool,
 				Optional: true,
-				Default:  3600,
+				Default:  false,
 			},
 			// Computed
 			"hostname": {
@@ -69,7 +80,7 @@ func resourceUltradnsRdpool() *schema.Resource {
 
 func resourceUltradnsRdpoolCreate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] ultradns_rdpool create")
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResourceFromRdpool(d)
 	if err != nil {
@@ -77,7 +88,7 @@ func resourceUltradnsRdpoolCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	log.Printf("[INFO] ultradns_rdpool create: %#v", r)
-	_, err = client.RRSets.Create(r.RRSetKey(), r.RRSet())
+	err = createRRSet(client, r, d.Get("replace_existing").(bool))
 	if err != nil {
 		return fmt.Errorf("create failed: %#v -> %v", r, err)
 	}
@@ -90,7 +101,7 @@ func resourceUltradnsRdpoolCreate(d *schema.ResourceData, meta interface{}) erro
 
 func resourceUltradnsRdpoolRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] ultradns_rdpool read")
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	rr, err := newRRSetResourceFromRdpool(d)
 	if err != nil {
@@ -99,16 +110,11 @@ func resourceUltradnsRdpoolRead(d *schema.ResourceData, meta interface{}) error
 
 	rrsets, err := client.RRSets.Select(rr.RRSetKey())
 	if err != nil {
-		uderr, ok := err.(*udnssdk.ErrorResponseList)
-		if ok {
-			for _, resps := range uderr.Responses {
-				// 70002 means Records Not Found
-				if resps.ErrorCode == 70002 {
-					d.SetId("")
-					return nil
-				}
-				return fmt.Errorf("resource not found: %v", err)
-			}
+		// Deleted outside Terraform, possibly along with its whole zone
+		if isRRSetNotFound(err) || isZoneNotFound(err) {
+			log.Printf("[INFO] ultradns_rdpool %s is gone, removing it from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
 		}
 		return fmt.Errorf("resource not found: %v", err)
 	}
@@ -142,7 +148,13 @@ func resourceUltradnsRdpoolRead(d *schema.ResourceData, meta interface{}) error
 	d.Set("description", p.Description)
 	d.Set("order", p.Order)
 
-	err = d.Set("rdata", makeSetFromStrings(r.RData))
+	// Only FIXED pools answer in rdata order, so otherwise keep the order
+	// of the configuration to avoid spurious diffs
+	rdata := r.RData
+	if !strings.EqualFold(p.Order, "FIXED") {
+		rdata = orderLike(r.RData, d.Get("rdata").([]interface{}))
+	}
+	err = d.Set("rdata", rdata)
 	if err != nil {
 		return fmt.Errorf("rdata set failed: %#v", err)
 	}
@@ -151,7 +163,7 @@ func resourceUltradnsRdpoolRead(d *schema.ResourceData, meta interface{}) error
 
 func resourceUltradnsRdpoolUpdate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] ultradns_rdpool update")
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResourceFromRdpool(d)
 	if err != nil {
@@ -169,7 +181,7 @@ func resourceUltradnsRdpoolUpdate(d *schema.ResourceData, meta interface{}) erro
 
 func resourceUltradnsRdpoolDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] ultradns_rdpool delete")
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResourceFromRdpool(d)
 	if err != nil {
@@ -178,7 +190,7 @@ func resourceUltradnsRdpoolDelete(d *schema.ResourceData, meta interface{}) erro
 
 	log.Printf("[INFO] ultradns_rdpool delete: %+v", r)
 	_, err = client.RRSets.Delete(r.RRSetKey())
-	if err != nil {
+	if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
 		return fmt.Errorf("resource delete failed: %v", err)
 	}
 
@@ -194,11 +206,11 @@ func newRRSetResourceFromRdpool(d *schema.ResourceData) (rRSetResource, error) {
 		// per https://portal.ultradns.com/static/docs/REST-API_User_Guide.pdf
 		RRType:    "A",
 		Zone:      d.Get("zone").(string),
-		OwnerName: d.Get("name").(string),
+		OwnerName: normalizeOwnerName(d.Get("name").(string), d.Get("zone").(string)),
 		TTL:       d.Get("ttl").(int),
 	}
 	if attr, ok := d.GetOk("rdata"); ok {
-		rdata := attr.(*schema.Set).List()
+		rdata := attr.([]interface{})
 		r.RData = make([]string, len(rdata))
 		for i, j := range rdata {
 			r.RData[i] = j.(string)
@@ -207,7 +219,7 @@ func newRRSetResourceFromRdpool(d *schema.ResourceData) (rRSetResource, error) {
 
 	profile := udnssdk.RDPoolProfile{
 		Context:     udnssdk.RDPoolSchema,
-		Order:       d.Get("order").(string),
+		Order:       strings.ToUpper(d.Get("order").(string)),
 		Description: d.Get("description").(string),
 	}
 
@@ -216,3 +228,29 @@ func newRRSetResourceFromRdpool(d *schema.ResourceData) (rRSetResource, error) {
 
 	return r, nil
 }
+
+// orderLike returns ss reordered so that values also present in prior
+// come first, in the order of prior, followed by the rest of ss in their
+// original order
+func orderLike(ss []string, prior []interface{}) []string {
+	remaining := map[string]int{}
+	for _, s := range ss {
+		remaining[s]++
+	}
+
+	res := make([]string, 0, len(ss))
+	for _, p := range prior {
+		s, _ := p.(string)
+		if remaining[s] > 0 {
+			remaining[s]--
+			res = append(res, s)
+		}
+	}
+	for _, s := range ss {
+		if remaining[s] > 0 {
+			remaining[s]--
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/terra-farm/udnssdk"
 )
 
 func TestAccUltradnsRdpool(t *testing.T) {
@@ -67,6 +67,55 @@ func TestAccUltradnsRdpool(t *testing.T) {
 	})
 }
 
+func TestAccUltradnsRdpoolFixedOrder(t *testing.T) {
+	var record udnssdk.RRSet
+	domain := "ultradns.phinze.com"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccRdpoolCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCfgRdpoolFixed, domain, "10.6.2.2", "10.6.2.1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUltradnsRecordExists("ultradns_rdpool.it", &record),
+					resource.TestCheckResourceAttr("ultradns_rdpool.it", "order", "FIXED"),
+					resource.TestCheckResourceAttr("ultradns_rdpool.it", "rdata.0", "10.6.2.2"),
+					resource.TestCheckResourceAttr("ultradns_rdpool.it", "rdata.1", "10.6.2.1"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testCfgRdpoolFixed, domain, "10.6.2.1", "10.6.2.2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUltradnsRecordExists("ultradns_rdpool.it", &record),
+					resource.TestCheckResourceAttr("ultradns_rdpool.it", "rdata.0", "10.6.2.1"),
+					resource.TestCheckResourceAttr("ultradns_rdpool.it", "rdata.1", "10.6.2.2"),
+				),
+			},
+		},
+	})
+}
+
+func TestOrderLike(t *testing.T) {
+	cases := []struct {
+		ss    []string
+		prior []interface{}
+		want  []string
+	}{
+		{[]string{"a", "b", "c"}, []interface{}{"c", "a", "b"}, []string{"c", "a", "b"}},
+		{[]string{"a", "b", "c"}, []interface{}{"c", "x"}, []string{"c", "a", "b"}},
+		{[]string{"a", "b"}, nil, []string{"a", "b"}},
+	}
+
+	for _, c := range cases {
+		got := orderLike(c.ss, c.prior)
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("orderLike(%v, %v) = %v, want %v", c.ss, c.prior, got, c.want)
+		}
+	}
+}
+
 const testCfgRdpoolMinimal = `
 resource "ultradns_rdpool" "it" {
   zone        = "%s"
@@ -98,3 +147,14 @@ resource "ultradns_rdpool" "it" {
   }
 }
 `
+
+const testCfgRdpoolFixed = `
+resource "ultradns_rdpool" "it" {
+  zone        = "%s"
+  name        = "test-rdpool-fixed"
+  order       = "fixed"
+  ttl         = 300
+  description = "Fixed order RD Pool"
+  rdata       = ["%s", "%s"]
+}
+`
@@ -1,14 +1,15 @@
 package ultradns
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/terra-farm/udnssdk"
 )
 
 func newRRSetResource(d *schema.ResourceData) (rRSetResource, error) {
@@ -16,23 +17,26 @@ func newRRSetResource(d *schema.ResourceData) (rRSetResource, error) {
 
 	// TODO: return error if required attributes aren't ok
 
+	if attr, ok := d.GetOk("zone"); ok {
+		r.Zone = attr.(string)
+	}
+
 	if attr, ok := d.GetOk("name"); ok {
-		r.OwnerName = attr.(string)
+		r.OwnerName = normalizeOwnerName(attr.(string), r.Zone)
 	}
 
 	if attr, ok := d.GetOk("type"); ok {
 		r.RRType = attr.(string)
 	}
 
-	if attr, ok := d.GetOk("zone"); ok {
-		r.Zone = attr.(string)
-	}
-
 	if attr, ok := d.GetOk("rdata"); ok {
 		rdata := attr.(*schema.Set).List()
 		r.RData = make([]string, len(rdata))
 		for i, j := range rdata {
 			r.RData[i] = j.(string)
+			if strings.EqualFold(r.RRType, "TXT") {
+				r.RData[i] = normalizeTXTRdata(r.RData[i])
+			}
 		}
 	}
 
@@ -46,8 +50,11 @@ func newRRSetResource(d *schema.ResourceData) (rRSetResource, error) {
 func populateResourceDataFromRRSet(r udnssdk.RRSet, d *schema.ResourceData) error {
 	zone := d.Get("zone")
 	typ := d.Get("type")
-	// ttl
-	d.Set("ttl", r.TTL)
+	// ttl, unless changes made outside Terraform are to be kept
+	if !d.Get("ignore_ttl_drift").(bool) || d.Get("ttl").(string) == "" {
+		d.Set("ttl", strconv.Itoa(r.TTL))
+	}
+	d.Set("current_ttl", r.TTL)
 	// rdata
 	rdata := r.RData
 
@@ -55,14 +62,7 @@ func populateResourceDataFromRRSet(r udnssdk.RRSet, d *schema.ResourceData) erro
 	if typ == "TXT" {
 		rdata = make([]string, len(r.RData))
 		for i := range r.RData {
-			var s string
-			err := json.Unmarshal([]byte(r.RData[i]), &s)
-			if err != nil {
-				log.Printf("[INFO] TXT answer parse error: %+v", err)
-				s = r.RData[i]
-			}
-			rdata[i] = s
-
+			rdata[i] = normalizeTXTRdata(r.RData[i])
 		}
 	}
 
@@ -70,6 +70,21 @@ func populateResourceDataFromRRSet(r udnssdk.RRSet, d *schema.ResourceData) erro
 	if err != nil {
 		return fmt.Errorf("ultradns_record.rdata set failed: %#v", err)
 	}
+	// rdata_info
+	poolType, infos, err := makeRdataInfos(rdata, r.Profile)
+	if err != nil {
+		return fmt.Errorf("ultradns_record.rdata_info conversion failed: %v", err)
+	}
+	if typ == "TXT" {
+		for _, info := range infos {
+			info["strings"] = splitTXTStrings(info["rdata"].(string))
+		}
+	}
+	d.Set("pool_type", poolType)
+	err = d.Set("rdata_info", infos)
+	if err != nil {
+		return fmt.Errorf("ultradns_record.rdata_info set failed: %#v", err)
+	}
 	// hostname
 	if r.OwnerName == "" {
 		d.Set("hostname", zone)
@@ -84,11 +99,18 @@ func populateResourceDataFromRRSet(r udnssdk.RRSet, d *schema.ResourceData) erro
 }
 
 func resourceUltradnsRecord() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Create: resourceUltraDNSRecordCreate,
 		Read:   resourceUltraDNSRecordRead,
 		Update: resourceUltraDNSRecordUpdate,
 		Delete: resourceUltraDNSRecordDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceUltraDNSRecordImport,
+		},
+
+		CustomizeDiff: resourceUltraDNSRecordCustomizeDiff,
+
+		SchemaVersion: 1,
 
 		Schema: map[string]*schema.Schema{
 			// Required
@@ -98,9 +120,10 @@ func resourceUltradnsRecord() *schema.Resource {
 				ForceNew: true,
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressOwnerNameDiff,
 			},
 			"type": {
 				Type:     schema.TypeString,
@@ -109,49 +132,195 @@ func resourceUltradnsRecord() *schema.Resource {
 			},
 			"rdata": {
 				Type:     schema.TypeSet,
-				Set:      schema.HashString,
+				Set:      hashRdataString,
 				Required: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateFunc:     validateRdataValue,
+					DiffSuppressFunc: suppressTXTRdataDiff,
+				},
 			},
 			// Optional
 			"ttl": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  "3600",
+				// The default is ignored when the zone's is used instead
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Get("use_zone_default_ttl").(bool)
+				},
+			},
+			"use_zone_default_ttl": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"ttl"},
+			},
+			"ignore_ttl_drift": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"use_zone_default_ttl"},
+			},
+			"manage_system_records": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"manage_apex_ns": {
+				Type:       schema.TypeBool,
+				Optional:   true,
+				Default:    false,
+				Deprecated: "Use manage_system_records instead",
+			},
+			"replace_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"create_ptr": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"validate_spf": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
 			},
 			// Computed
 			"hostname": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"zone_default_ttl": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"current_ttl": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"ptr": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"zone": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"pool_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"rdata_info": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"rdata": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"group": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"strings": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"priority": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"weight": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"threshold": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"run_probes": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"available_to_serve": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
 		},
 	}
+
+	// Version 0 had the same attributes and only differed in its ID
+	r.StateUpgraders = []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    r.CoreConfigSchema().ImpliedType(),
+			Upgrade: resourceUltraDNSRecordStateUpgradeV0,
+		},
+	}
+	return r
 }
 
 // CRUD Operations
 
 func resourceUltraDNSRecordCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResource(d)
 	if err != nil {
 		return err
 	}
 
+	err = resolveZoneDefaultTTL(client, d, &r)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[INFO] ultradns_record create: %+v", r)
-	_, err = client.RRSets.Create(r.RRSetKey(), r.RRSet())
+	err = createRRSet(client, r, d.Get("replace_existing").(bool))
 	if err != nil {
 		return fmt.Errorf("create failed: %v", err)
 	}
 
-	d.SetId(r.ID())
+	d.SetId(recordID(r))
 	log.Printf("[INFO] ultradns_record.id: %v", d.Id())
 
+	if d.Get("create_ptr").(bool) {
+		ptrs, err := syncPTRRecords(client, r, nil)
+		d.Set("ptr", makePTRRecords(ptrs))
+		if err != nil {
+			return err
+		}
+	}
+
 	return resourceUltraDNSRecordRead(d, meta)
 }
 
 func resourceUltraDNSRecordRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResource(d)
 	if err != nil {
@@ -160,55 +329,373 @@ func resourceUltraDNSRecordRead(d *schema.ResourceData, meta interface{}) error
 
 	rrsets, err := client.RRSets.Select(r.RRSetKey())
 	if err != nil {
-		uderr, ok := err.(*udnssdk.ErrorResponseList)
-		if ok {
-			for _, r := range uderr.Responses {
-				// 70002 means Records Not Found
-				if r.ErrorCode == 70002 {
-					d.SetId("")
-					return nil
-				}
-				return fmt.Errorf("not found: %v", err)
-			}
+		// Deleted outside Terraform, possibly along with its whole zone
+		if isRRSetNotFound(err) || isZoneNotFound(err) {
+			log.Printf("[INFO] ultradns_record %s is gone, removing it from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
 		}
 		return fmt.Errorf("not found: %v", err)
 	}
 	rec := rrsets[0]
+	if pool := describePoolProfile(rec.Profile); pool != "" {
+		log.Printf("[WARN] ultradns_record %s %s is a %s", r.ID(), r.RRType, pool)
+	}
+
+	ptrs, err := readPTRRecords(client, ptrRecordsFromList(d.Get("ptr").([]interface{})))
+	if err != nil {
+		return err
+	}
+	d.Set("ptr", makePTRRecords(ptrs))
+
 	return populateResourceDataFromRRSet(rec, d)
 }
 
 func resourceUltraDNSRecordUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResource(d)
 	if err != nil {
 		return err
 	}
 
+	err = checkNotPool(client, r)
+	if err != nil {
+		return err
+	}
+
+	err = resolveZoneDefaultTTL(client, d, &r)
+	if err != nil {
+		return err
+	}
+	// Keep a TTL changed outside Terraform unless ttl itself changed
+	if d.Get("ignore_ttl_drift").(bool) && !d.HasChange("ttl") && d.Get("current_ttl").(int) > 0 {
+		r.TTL = d.Get("current_ttl").(int)
+	}
+
 	log.Printf("[INFO] ultradns_record update: %+v", r)
 	_, err = client.RRSets.Update(r.RRSetKey(), r.RRSet())
 	if err != nil {
 		return fmt.Errorf("update failed: %v", err)
 	}
 
+	// The reverse records in state, as ptr is planned to be recomputed
+	o, _ := d.GetChange("ptr")
+	managed := ptrRecordsFromList(o.([]interface{}))
+	if d.Get("create_ptr").(bool) {
+		ptrs, err := syncPTRRecords(client, r, managed)
+		d.Set("ptr", makePTRRecords(ptrs))
+		if err != nil {
+			return err
+		}
+	} else if len(managed) > 0 {
+		err = deletePTRRecords(client, r, managed)
+		if err != nil {
+			return err
+		}
+		d.Set("ptr", nil)
+	}
+
 	return resourceUltraDNSRecordRead(d, meta)
 }
 
 func resourceUltraDNSRecordDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResource(d)
 	if err != nil {
 		return err
 	}
 
+	err = checkSystemRecord(r, d.Get("manage_system_records").(bool), d.Get("manage_apex_ns").(bool))
+	if err != nil {
+		return err
+	}
+
+	err = checkNotPool(client, r)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[INFO] ultradns_record delete: %+v", r)
 	_, err = client.RRSets.Delete(r.RRSetKey())
-	if err != nil {
+	if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
 		return fmt.Errorf("delete failed: %v", err)
 	}
 
+	return deletePTRRecords(client, r, ptrRecordsFromList(d.Get("ptr").([]interface{})))
+}
+
+func resourceUltraDNSRecordImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	name, zone, typ, err := parseRecordID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	// Store the ID create would have made, whichever way the name and
+	// type were written
+	r := rRSetResource{OwnerName: normalizeOwnerName(name, zone), Zone: zone, RRType: strings.ToUpper(typ)}
+	d.SetId(recordID(r))
+	d.Set("name", r.OwnerName)
+	d.Set("zone", r.Zone)
+	d.Set("type", r.RRType)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceUltraDNSRecordStateUpgradeV0 replaces the "name.zone" IDs of
+// version 0, which did not identify the RRType, with recordID
+func resourceUltraDNSRecordStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	r := rRSetResource{}
+	r.OwnerName, _ = rawState["name"].(string)
+	r.Zone, _ = rawState["zone"].(string)
+	r.RRType, _ = rawState["type"].(string)
+
+	id := recordID(r)
+	log.Printf("[INFO] ultradns_record state upgrade: id %v -> %v", rawState["id"], id)
+	rawState["id"] = id
+	return rawState, nil
+}
+
+func resourceUltraDNSRecordCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	r := rRSetResource{
+		OwnerName: d.Get("name").(string),
+		RRType:    d.Get("type").(string),
+		Zone:      d.Get("zone").(string),
+	}
+	// Only a plan that writes the RRSet is refused, so that a record
+	// managed before the guard existed still plans cleanly when it is
+	// left alone; Delete checks again
+	var err error
+	if d.Id() == "" || hasRecordWrite(d) {
+		err = checkSystemRecord(r, d.Get("manage_system_records").(bool), d.Get("manage_apex_ns").(bool))
+		if err != nil {
+			return err
+		}
+	}
+	if d.NewValueKnown("rdata") {
+		rdata := stringsFromList(d.Get("rdata").(*schema.Set).List())
+		err = checkRdata(r.RRType, rdata)
+		if err != nil {
+			return err
+		}
+		err = checkOverlappingRdata(r.RRType, rdata)
+		if err != nil {
+			return err
+		}
+		if d.Get("validate_spf").(bool) && isTXTType(r.RRType) {
+			if err := checkSPFRdata(rdata); err != nil {
+				return fmt.Errorf("ultradns_record %s %s: %v", r.ID(), r.RRType, err)
+			}
+		}
+	}
+
+	if d.Get("create_ptr").(bool) {
+		if !strings.EqualFold(r.RRType, "A") && !strings.EqualFold(r.RRType, "AAAA") {
+			return fmt.Errorf("create_ptr is only supported for A and AAAA records, not %s", r.RRType)
+		}
+		if d.Id() != "" && (!d.NewValueKnown("rdata") || !samePTRAddresses(d.Get("ptr").([]interface{}), d.Get("rdata").(*schema.Set).List())) {
+			err = d.SetNewComputed("ptr")
+			if err != nil {
+				return err
+			}
+		}
+	} else if d.HasChange("create_ptr") {
+		err = d.SetNewComputed("ptr")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Follow changes to the zone's default TTL, and out-of-band edits of
+	// the record's, by planning an update when they no longer match
+	if d.Get("use_zone_default_ttl").(bool) && d.Id() != "" {
+		ttl, err := findZoneDefaultTTL(meta.(*Client), r.Zone)
+		if err != nil {
+			return fmt.Errorf("reading the default TTL of zone %q failed: %v", r.Zone, err)
+		}
+		old, _ := d.GetChange("ttl")
+		if old.(string) != strconv.Itoa(ttl) {
+			return d.SetNewComputed("zone_default_ttl")
+		}
+	}
+	return nil
+}
+
+// recordWriteKeys are the arguments of ultradns_record whose change
+// writes the RRSet
+var recordWriteKeys = []string{"zone", "name", "type", "rdata", "ttl", "use_zone_default_ttl"}
+
+// hasRecordWrite reports whether d changes what is written to the RRSet
+func hasRecordWrite(d *schema.ResourceDiff) bool {
+	for _, k := range recordWriteKeys {
+		if d.HasChange(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePTRAddresses reports whether the managed reverse records cover
+// exactly the addresses in rdata, so that none needs writing
+func samePTRAddresses(ptrs []interface{}, rdata []interface{}) bool {
+	ips := map[string]bool{}
+	for _, p := range ptrRecordsFromList(ptrs) {
+		ips[p.IP] = true
+	}
+	if len(ips) != len(rdata) {
+		return false
+	}
+	for _, v := range rdata {
+		ip := net.ParseIP(v.(string))
+		if ip == nil || !ips[ip.String()] {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveZoneDefaultTTL sets the TTL of r to the zone's default when
+// use_zone_default_ttl is set
+func resolveZoneDefaultTTL(client *Client, d *schema.ResourceData, r *rRSetResource) error {
+	if !d.Get("use_zone_default_ttl").(bool) {
+		d.Set("zone_default_ttl", 0)
+		return nil
+	}
+	ttl, err := findZoneDefaultTTL(client, r.Zone)
+	if err != nil {
+		return fmt.Errorf("reading the default TTL of zone %q failed: %v", r.Zone, err)
+	}
+	r.TTL = ttl
+	d.Set("zone_default_ttl", ttl)
 	return nil
 }
 
 // Conversion helper functions
+
+// checkNotPool refuses to write the RRSet of r when it has become a
+// pool, which a plain update or delete would destroy
+func checkNotPool(client *Client, r rRSetResource) error {
+	rrsets, err := client.RRSets.Select(r.RRSetKey())
+	if err != nil || len(rrsets) == 0 {
+		// Let the write itself report the problem
+		return nil
+	}
+	if pool := describePoolProfile(rrsets[0].Profile); pool != "" {
+		return fmt.Errorf("ultradns_record %s %s is now a %s. Writing it as a plain record would destroy the pool; "+
+			"remove it from state with terraform state rm to stop managing it here", r.ID(), r.RRType, pool)
+	}
+	return nil
+}
+
+// makeRdataInfos returns the pool type of profile, "" for a plain
+// record, and the per-rdata metadata it holds in the appropriate
+// structure for the schema
+func makeRdataInfos(rdata []string, profile udnssdk.RawProfile) (string, []map[string]interface{}, error) {
+	infos := make([]map[string]interface{}, len(rdata))
+	for i, r := range rdata {
+		infos[i] = map[string]interface{}{"rdata": r}
+	}
+	if profile == nil {
+		return "", infos, nil
+	}
+
+	// Read @context directly, as RawProfile.Context panics without it
+	c, _ := profile["@context"].(string)
+	poolType := poolProfileTypes[udnssdk.ProfileSchema(c)][0]
+	if poolType == "" {
+		poolType = c
+	}
+
+	var sbInfos []udnssdk.SBRDataInfo
+	switch udnssdk.ProfileSchema(c) {
+	case udnssdk.DirPoolSchema:
+		p, err := profile.DirPoolProfile()
+		if err != nil {
+			return "", nil, err
+		}
+		for i, info := range p.RDataInfo {
+			if i >= len(infos) {
+				break
+			}
+			switch {
+			case info.GeoInfo != nil:
+				infos[i]["group"] = info.GeoInfo.Name
+			case info.IPInfo != nil:
+				infos[i]["group"] = info.IPInfo.Name
+			}
+		}
+		return poolType, infos, nil
+	case udnssdk.SBPoolSchema:
+		p, err := profile.SBPoolProfile()
+		if err != nil {
+			return "", nil, err
+		}
+		sbInfos = p.RDataInfo
+	case udnssdk.TCPoolSchema:
+		p, err := profile.TCPoolProfile()
+		if err != nil {
+			return "", nil, err
+		}
+		sbInfos = p.RDataInfo
+	}
+	for i, info := range sbInfos {
+		if i >= len(infos) {
+			break
+		}
+		infos[i]["state"] = info.State
+		infos[i]["priority"] = info.Priority
+		infos[i]["weight"] = info.Weight
+		infos[i]["threshold"] = info.Threshold
+		infos[i]["run_probes"] = info.RunProbes
+		infos[i]["available_to_serve"] = info.AvailableToServe
+	}
+	return poolType, infos, nil
+}
+
+// recordID returns the ID of an ultradns_record, "name:zone:type", which
+// unlike rRSetResource.ID tells apart the RRSets of an owner name
+func recordID(r rRSetResource) string {
+	return fmt.Sprintf("%s:%s:%s", escapeRecordIDPart(r.OwnerName), escapeRecordIDPart(r.Zone), r.RRType)
+}
+
+// escapeRecordIDPart percent-encodes the characters that would make a
+// recordID ambiguous, leaving every other name, including "*" and "_"
+// labels, as is
+func escapeRecordIDPart(s string) string {
+	return strings.NewReplacer("%", "%25", ":", "%3A").Replace(s)
+}
+
+// parseRecordID splits an ID made by recordID. Any percent-encoded
+// character is decoded, so an owner name copied from an API URL, such as
+// "%2A" for "*", is accepted too.
+func parseRecordID(id string) (name, zone, typ string, err error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("ultradns_record ID %q must have the form name:zone:type", id)
+	}
+	for i := range parts[:2] {
+		parts[i], err = url.PathUnescape(parts[i])
+		if err != nil {
+			return "", "", "", fmt.Errorf("ultradns_record ID %q: %v", id, err)
+		}
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// checkSystemRecord refuses changes to the RRSets UltraDNS generates for
+// a zone, its SOA and the NS set at the apex, unless
+// manage_system_records is set. The deprecated manage_apex_ns allows the
+// apex NS set only.
+func checkSystemRecord(r rRSetResource, manageSystemRecords, manageApexNS bool) error {
+	if manageSystemRecords {
+		return nil
+	}
+	switch {
+	case strings.EqualFold(r.RRType, "SOA"):
+		return fmt.Errorf("ultradns_record %q is the SOA of zone %q: UltraDNS maintains it, and a wrong serial or timer can stop "+
+			"secondaries from transferring the zone. Set manage_system_records = true to manage it anyway", r.OwnerName, r.Zone)
+	case strings.EqualFold(r.RRType, "NS") && isApexOwner(r.OwnerName, r.Zone) && !manageApexNS:
+		return fmt.Errorf("ultradns_record %q is the apex NS set of zone %q: changing it can break delegation of the whole zone. "+
+			"Set manage_system_records = true to manage it anyway; NS records for child-zone delegations need no flag", r.OwnerName, r.Zone)
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
+)
+
+func resourceUltradnsRecordSetGroup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceUltradnsRecordSetGroupCreate,
+		Read:   resourceUltradnsRecordSetGroupRead,
+		Update: resourceUltradnsRecordSetGroupUpdate,
+		Delete: resourceUltradnsRecordSetGroupDelete,
+
+		CustomizeDiff: resourceUltradnsRecordSetGroupCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			// Required
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"record": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						// Required
+						"key": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"name": {
+							Type:             schema.TypeString,
+							Required:         true,
+							DiffSuppressFunc: suppressOwnerNameDiff,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"rdata": {
+							Type:     schema.TypeSet,
+							Set:      hashRdataString,
+							Required: true,
+							Elem: &schema.Schema{
+								Type:             schema.TypeString,
+								ValidateFunc:     validateRdataValue,
+								DiffSuppressFunc: suppressTXTRdataDiff,
+							},
+						},
+						// Optional
+						"ttl": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      3600,
+							ValidateFunc: validation.IntBetween(0, maxTTL),
+						},
+					},
+				},
+			},
+			// Optional
+			"validate_spf": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+// CRUD Operations
+
+func resourceUltradnsRecordSetGroupCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	zone := d.Get("zone").(string)
+
+	records := d.Get("record").(*schema.Set).List()
+	keys := make([]string, len(records))
+	for i, m := range records {
+		keys[i] = m.(map[string]interface{})["key"].(string)
+	}
+	sort.Strings(keys)
+	d.SetId(fmt.Sprintf("%s:%d", zone, hashcode.String(strings.Join(keys, ","))))
+	log.Printf("[INFO] ultradns_record_set_group.id: %v", d.Id())
+
+	calls := make([]batchCall, len(records))
+	for i, m := range records {
+		r := newRRSetResourceFromRecordSetGroup(zone, m.(map[string]interface{}))
+		log.Printf("[INFO] ultradns_record_set_group create: %+v", r)
+		calls[i] = rRSetBatchCall("POST", r)
+	}
+	errs, err := sendBatch(client, calls)
+	if err != nil {
+		d.SetId("")
+		return fmt.Errorf("create of %d records failed: %v", len(records), err)
+	}
+
+	// Keep the records that were created in state
+	created := []interface{}{}
+	var failures []string
+	for i, m := range records {
+		if errs[i] == nil {
+			created = append(created, m)
+			continue
+		}
+		r := newRRSetResourceFromRecordSetGroup(zone, m.(map[string]interface{}))
+		failures = append(failures, fmt.Sprintf("create of %s %s failed: %v", r.ID(), r.RRType, explainCreateFailure(client, r, errs[i])))
+	}
+	if len(failures) > 0 {
+		d.Set("record", created)
+		if len(created) == 0 {
+			d.SetId("")
+		}
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	return resourceUltradnsRecordSetGroupRead(d, meta)
+}
+
+func resourceUltradnsRecordSetGroupRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	zone := d.Get("zone").(string)
+
+	records := []interface{}{}
+	for _, v := range d.Get("record").(*schema.Set).List() {
+		m := v.(map[string]interface{})
+		r := newRRSetResourceFromRecordSetGroup(zone, m)
+		rrsets, err := client.RRSets.Select(r.RRSetKey())
+		if err != nil {
+			// Deleted outside Terraform, possibly along with its whole zone
+			if isRRSetNotFound(err) || isZoneNotFound(err) {
+				log.Printf("[INFO] ultradns_record_set_group %s: %s %s is gone, removing it from state: %v", d.Id(), r.ID(), r.RRType, err)
+				continue
+			}
+			return fmt.Errorf("read of %s %s failed: %v", r.ID(), r.RRType, err)
+		}
+		records = append(records, mapFromRecordSetGroupRRSet(m, rrsets[0]))
+	}
+
+	err := d.Set("record", records)
+	if err != nil {
+		return fmt.Errorf("ultradns_record_set_group.record set failed: %#v", err)
+	}
+	return nil
+}
+
+func resourceUltradnsRecordSetGroupUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	zone := d.Get("zone").(string)
+
+	o, n := d.GetChange("record")
+	olds := recordSetGroupRecordsByKey(o.(*schema.Set))
+	news := recordSetGroupRecordsByKey(n.(*schema.Set))
+
+	// current tracks what UltraDNS holds, so that a failed write leaves
+	// exactly the records already written in state
+	current := map[string]interface{}{}
+	for k, m := range olds {
+		current[k] = m
+	}
+	fail := func(err error) error {
+		records := []interface{}{}
+		for _, m := range current {
+			records = append(records, m)
+		}
+		d.Set("record", records)
+		return err
+	}
+
+	// Delete removed records, and records whose owner or type changed,
+	// first, so that a record may move between keys. Each call is
+	// applied to current once the batch says it succeeded.
+	calls := []batchCall{}
+	applied := []func(){}
+	describe := []string{}
+	for k, m := range olds {
+		r := newRRSetResourceFromRecordSetGroup(zone, m)
+		if nm, ok := news[k]; ok && newRRSetResourceFromRecordSetGroup(zone, nm).RRSetKey() == r.RRSetKey() {
+			continue
+		}
+		err := checkNotPool(client, r)
+		if err != nil {
+			return fail(err)
+		}
+		log.Printf("[INFO] ultradns_record_set_group delete: %+v", r)
+		k := k
+		calls = append(calls, rRSetBatchCall("DELETE", r))
+		applied = append(applied, func() { delete(current, k) })
+		describe = append(describe, fmt.Sprintf("delete of %s %s", r.ID(), r.RRType))
+	}
+
+	creates := map[int]rRSetResource{}
+	for k, m := range news {
+		r := newRRSetResourceFromRecordSetGroup(zone, m)
+		k, m := k, m
+		if om, ok := olds[k]; !ok || newRRSetResourceFromRecordSetGroup(zone, om).RRSetKey() != r.RRSetKey() {
+			log.Printf("[INFO] ultradns_record_set_group create: %+v", r)
+			creates[len(calls)] = r
+			calls = append(calls, rRSetBatchCall("POST", r))
+			applied = append(applied, func() { current[k] = m })
+			describe = append(describe, fmt.Sprintf("create of %s %s", r.ID(), r.RRType))
+			continue
+		}
+		if hashRecordSetGroupRecordContent(olds[k]) == hashRecordSetGroupRecordContent(m) {
+			continue
+		}
+		err := checkNotPool(client, r)
+		if err != nil {
+			return fail(err)
+		}
+		log.Printf("[INFO] ultradns_record_set_group update: %+v", r)
+		calls = append(calls, rRSetBatchCall("PUT", r))
+		applied = append(applied, func() { current[k] = m })
+		describe = append(describe, fmt.Sprintf("update of %s %s", r.ID(), r.RRType))
+	}
+
+	errs, err := sendBatch(client, calls)
+	if err != nil {
+		return fail(fmt.Errorf("update of %d records failed: %v", len(calls), err))
+	}
+	var failures []string
+	for i, err := range errs {
+		if err != nil && calls[i].Method == "DELETE" && (isRRSetNotFound(err) || isZoneNotFound(err)) {
+			err = nil
+		}
+		if err != nil {
+			if r, ok := creates[i]; ok {
+				err = explainCreateFailure(client, r, err)
+			}
+			failures = append(failures, fmt.Sprintf("%s failed: %v", describe[i], err))
+			continue
+		}
+		applied[i]()
+	}
+	if len(failures) > 0 {
+		return fail(fmt.Errorf("%s", strings.Join(failures, "; ")))
+	}
+
+	return resourceUltradnsRecordSetGroupRead(d, meta)
+}
+
+func resourceUltradnsRecordSetGroupDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	zone := d.Get("zone").(string)
+
+	records := d.Get("record").(*schema.Set).List()
+	rs := make([]rRSetResource, len(records))
+	calls := make([]batchCall, len(records))
+	for i, m := range records {
+		rs[i] = newRRSetResourceFromRecordSetGroup(zone, m.(map[string]interface{}))
+		err := checkNotPool(client, rs[i])
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] ultradns_record_set_group delete: %+v", rs[i])
+		calls[i] = rRSetBatchCall("DELETE", rs[i])
+	}
+	errs, err := sendBatch(client, calls)
+	if err != nil {
+		return fmt.Errorf("delete of %d records failed: %v", len(records), err)
+	}
+
+	// Keep the records that could not be deleted in state
+	remaining := []interface{}{}
+	var failures []string
+	for i, err := range errs {
+		if err == nil || isRRSetNotFound(err) || isZoneNotFound(err) {
+			continue
+		}
+		remaining = append(remaining, records[i])
+		failures = append(failures, fmt.Sprintf("delete of %s %s failed: %v", rs[i].ID(), rs[i].RRType, err))
+	}
+	if len(failures) > 0 {
+		d.Set("record", remaining)
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// resourceUltradnsRecordSetGroupCustomizeDiff refuses groups in which
+// two records have the same key, or the same owner and type, as they
+// would overwrite each other, and records UltraDNS maintains itself
+func resourceUltradnsRecordSetGroupCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	zone := d.Get("zone").(string)
+	return checkRecordSetGroup(zone, d.Get("record").(*schema.Set).List(), d.Get("validate_spf").(bool))
+}
+
+// Conversion helper functions
+
+func checkRecordSetGroup(zone string, records []interface{}, validateSPF bool) error {
+	keys := map[string]bool{}
+	rrsets := map[string]string{}
+	for _, v := range records {
+		m := v.(map[string]interface{})
+		key := m["key"].(string)
+		if keys[key] {
+			return fmt.Errorf("ultradns_record_set_group has more than one record with key %q", key)
+		}
+		keys[key] = true
+
+		r := newRRSetResourceFromRecordSetGroup(zone, m)
+		if r.OwnerName == "" || r.RRType == "" {
+			// Not known until apply
+			continue
+		}
+		err := checkSystemRecord(r, false, false)
+		if err != nil {
+			return fmt.Errorf("ultradns_record_set_group record %q: %v", key, err)
+		}
+		err = checkRdata(r.RRType, r.RData)
+		if err != nil {
+			return fmt.Errorf("ultradns_record_set_group record %q: %v", key, err)
+		}
+		err = checkOverlappingRdata(r.RRType, r.RData)
+		if err != nil {
+			return fmt.Errorf("ultradns_record_set_group record %q: %v", key, err)
+		}
+		if validateSPF && isTXTType(r.RRType) {
+			if err := checkSPFRdata(r.RData); err != nil {
+				return fmt.Errorf("ultradns_record_set_group record %q: %v", key, err)
+			}
+		}
+		id := strings.ToLower(fmt.Sprintf("%s %s", fqdnOwner(r.OwnerName, zone), r.RRType))
+		if other, ok := rrsets[id]; ok {
+			return fmt.Errorf("ultradns_record_set_group records %q and %q are both the %s %s RRSet", other, key, r.ID(), r.RRType)
+		}
+		rrsets[id] = key
+	}
+	return nil
+}
+
+func newRRSetResourceFromRecordSetGroup(zone string, m map[string]interface{}) rRSetResource {
+	r := rRSetResource{
+		OwnerName: normalizeOwnerName(m["name"].(string), zone),
+		RRType:    strings.ToUpper(m["type"].(string)),
+		Zone:      zone,
+		TTL:       m["ttl"].(int),
+	}
+	if rdata, ok := m["rdata"].(*schema.Set); ok {
+		for _, v := range rdata.List() {
+			s := v.(string)
+			if r.RRType == "TXT" {
+				s = normalizeTXTRdata(s)
+			}
+			r.RData = append(r.RData, s)
+		}
+	}
+	return r
+}
+
+// mapFromRecordSetGroupRRSet encodes the RRSet read for record m into
+// the structure of the record schema
+func mapFromRecordSetGroupRRSet(m map[string]interface{}, r udnssdk.RRSet) map[string]interface{} {
+	rdata := r.RData
+	if strings.EqualFold(m["type"].(string), "TXT") {
+		rdata = make([]string, len(r.RData))
+		for i := range r.RData {
+			rdata[i] = normalizeTXTRdata(r.RData[i])
+		}
+	}
+	return map[string]interface{}{
+		"key":   m["key"],
+		"name":  m["name"],
+		"type":  m["type"],
+		"ttl":   r.TTL,
+		"rdata": makeSetFromStrings(rdata),
+	}
+}
+
+func recordSetGroupRecordsByKey(s *schema.Set) map[string]map[string]interface{} {
+	byKey := map[string]map[string]interface{}{}
+	for _, v := range s.List() {
+		m := v.(map[string]interface{})
+		byKey[m["key"].(string)] = m
+	}
+	return byKey
+}
+
+// hashRecordSetGroupRecordContent hashes everything about a record that
+// is written to UltraDNS
+func hashRecordSetGroupRecordContent(m map[string]interface{}) int {
+	rdata := []string{}
+	for _, v := range m["rdata"].(*schema.Set).List() {
+		rdata = append(rdata, normalizeTXTRdata(v.(string)))
+	}
+	sort.Strings(rdata)
+	return hashcode.String(fmt.Sprintf("%s %s %d %s", m["name"], strings.ToUpper(m["type"].(string)), m["ttl"], strings.Join(rdata, "\n")))
+}
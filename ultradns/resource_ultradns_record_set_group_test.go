@@ -0,0 +1,202 @@
+package ultradns
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/terra-farm/udnssdk"
+)
+
+func TestAccUltradnsRecordSetGroup(t *testing.T) {
+	domain := "ultradns.phinze.com"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccRecordSetGroupCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testCfgRecordSetGroup, domain, 300),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ultradns_record_set_group.it", "zone", domain),
+					resource.TestCheckResourceAttr("ultradns_record_set_group.it", "record.#", "2"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testCfgRecordSetGroup, domain, 600),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("ultradns_record_set_group.it", "record.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestResourceUltradnsRecordSetGroup_mock(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	r := resourceUltradnsRecordSetGroup()
+	record := func(key, name, typ string, ttl int, rdata ...string) map[string]interface{} {
+		rd := make([]interface{}, len(rdata))
+		for i, s := range rdata {
+			rd[i] = s
+		}
+		return map[string]interface{}{"key": key, "name": name, "type": typ, "ttl": ttl, "rdata": rd}
+	}
+	apply := func(state *terraform.InstanceState, records ...map[string]interface{}) *terraform.InstanceState {
+		rs := make([]interface{}, len(records))
+		for i, m := range records {
+			rs[i] = m
+		}
+		cfg := terraform.NewResourceConfigRaw(map[string]interface{}{"zone": "example.com", "record": rs})
+		diff, err := r.Diff(state, cfg, client)
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		state, err = r.Apply(state, diff, client)
+		if err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+		return state
+	}
+	rrset := func(name, typ string) *udnssdk.RRSet {
+		rrsets, err := client.RRSets.Select(udnssdk.RRSetKey{Zone: "example.com", Name: name, Type: typ})
+		if isRRSetNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatalf("Select %s %s: %v", name, typ, err)
+		}
+		return &rrsets[0]
+	}
+
+	state := apply(nil,
+		record("web", "www", "A", 300, "192.0.2.1"),
+		record("verify", "@", "TXT", 300, `"token=abc"`),
+	)
+	if state.Attributes["record.#"] != "2" {
+		t.Errorf("after create: %v", state.Attributes)
+	}
+	if rr := rrset("www", "A"); rr == nil || rr.TTL != 300 {
+		t.Errorf("after create: www A = %+v", rr)
+	}
+	if rr := rrset("example.com.", "TXT"); rr == nil || rr.RData[0] != "token=abc" {
+		t.Errorf("after create: TXT = %+v, want its rdata sent unquoted", rr)
+	}
+
+	// Change one record in place, move another to a new owner, add one
+	state = apply(state,
+		record("web", "www", "A", 600, "192.0.2.1"),
+		record("verify", "_verify", "TXT", 300, "token=abc"),
+		record("api", "api", "CNAME", 300, "www.example.com."),
+	)
+	if rr := rrset("www", "A"); rr == nil || rr.TTL != 600 {
+		t.Errorf("after update: www A = %+v", rr)
+	}
+	if rrset("example.com.", "TXT") != nil || rrset("_verify", "TXT") == nil || rrset("api", "CNAME") == nil {
+		t.Errorf("after update: records not moved or added")
+	}
+	if state.Attributes["record.#"] != "3" {
+		t.Errorf("after update: %v", state.Attributes)
+	}
+
+	if _, err := r.Apply(state, &terraform.InstanceDiff{Destroy: true}, client); err != nil {
+		t.Fatalf("destroy: %v", err)
+	}
+	for _, k := range [][2]string{{"www", "A"}, {"_verify", "TXT"}, {"api", "CNAME"}} {
+		if rrset(k[0], k[1]) != nil {
+			t.Errorf("after destroy: %s %s still exists", k[0], k[1])
+		}
+	}
+
+	// The batch goes on past a failed call, and the records it wrote
+	// stay in state
+	taken := rRSetResource{OwnerName: "taken", RRType: "A", Zone: "example.com", TTL: 300, RData: []string{"192.0.2.9"}}
+	if err := createRRSet(client, taken, false); err != nil {
+		t.Fatalf("createRRSet: %v", err)
+	}
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{"zone": "example.com", "record": []interface{}{
+		record("taken", "taken", "A", 300, "192.0.2.1"),
+		record("free", "free", "A", 300, "192.0.2.2"),
+	}})
+	diff, err := r.Diff(nil, cfg, client)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	state, err = r.Apply(nil, diff, client)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("create over an existing record: got %v", err)
+	}
+	if state == nil || state.Attributes["record.#"] != "1" || rrset("free", "A") == nil {
+		t.Errorf("after a partial create: %v", state)
+	}
+}
+
+func TestCheckRecordSetGroup(t *testing.T) {
+	m := func(key, name, typ string) interface{} {
+		return map[string]interface{}{"key": key, "name": name, "type": typ, "ttl": 300}
+	}
+	cases := []struct {
+		records []interface{}
+		err     bool
+	}{
+		{[]interface{}{m("a", "www", "A"), m("b", "www", "AAAA")}, false},
+		{[]interface{}{m("a", "www", "A"), m("a", "api", "A")}, true},
+		{[]interface{}{m("a", "www", "A"), m("b", "www.example.com.", "a")}, true},
+		{[]interface{}{m("a", "@", "SOA")}, true},
+	}
+	for _, c := range cases {
+		if err := checkRecordSetGroup("example.com", c.records, false); (err != nil) != c.err {
+			t.Errorf("checkRecordSetGroup(%v) = %v, want error: %v", c.records, err, c.err)
+		}
+	}
+}
+
+func testAccRecordSetGroupCheckDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "ultradns_record_set_group" {
+			continue
+		}
+
+		for _, name := range []string{"test-group-www", "test-group-api"} {
+			k := udnssdk.RRSetKey{
+				Zone: rs.Primary.Attributes["zone"],
+				Type: "ANY",
+				Name: name,
+			}
+			if _, err := client.RRSets.Select(k); err == nil {
+				return fmt.Errorf("Record %s still exists", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+const testCfgRecordSetGroup = `
+resource "ultradns_record_set_group" "it" {
+  zone = "%s"
+
+  record {
+    key   = "www"
+    name  = "test-group-www"
+    type  = "A"
+    ttl   = %d
+    rdata = ["10.5.0.1"]
+  }
+
+  record {
+    key   = "api"
+    name  = "test-group-api"
+    type  = "CNAME"
+    rdata = ["test-group-www.ultradns.phinze.com."]
+  }
+}
+`
@@ -2,11 +2,15 @@ package ultradns
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/terra-farm/udnssdk"
 )
 
 func TestAccUltradnsRecord(t *testing.T) {
@@ -46,6 +50,12 @@ func TestAccUltradnsRecord(t *testing.T) {
 					resource.TestCheckResourceAttr("ultradns_record.it", "rdata.1998004057", "10.5.0.2"),
 				),
 			},
+			{
+				ResourceName:            "ultradns_record.it",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: importRecordIgnore,
+			},
 		},
 	})
 }
@@ -76,8 +86,449 @@ func TestAccUltradnsRecordTXT(t *testing.T) {
 	})
 }
 
+func TestAccUltradnsRecordNSDelegation(t *testing.T) {
+	var record udnssdk.RRSet
+	domain := "ultradns.phinze.com"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccRecordCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testCfgRecordApexNS, domain, domain),
+				ExpectError: regexp.MustCompile("manage_system_records"),
+			},
+			{
+				Config: fmt.Sprintf(testCfgRecordNSDelegation, domain),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUltradnsRecordExists("ultradns_record.it", &record),
+					resource.TestCheckResourceAttr("ultradns_record.it", "name", "test-delegation"),
+					resource.TestCheckResourceAttr("ultradns_record.it", "type", "NS"),
+				),
+			},
+		},
+	})
+}
+
+// TestUltradnsRecord_mock runs without TF_ACC: every step configures the
+// provider anew, and must see the records of the steps before
+// importRecordIgnore lists the ultradns_record arguments that only
+// steer the provider, and are not read back on import
+var importRecordIgnore = []string{
+	"create_ptr", "ignore_ttl_drift", "manage_apex_ns", "manage_system_records",
+	"replace_existing", "use_zone_default_ttl", "validate_spf", "zone_default_ttl",
+}
+
+func TestUltradnsRecord_mock(t *testing.T) {
+	var record udnssdk.RRSet
+	domain := "example.com"
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccRecordCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testCfgMockProvider + fmt.Sprintf(testCfgRecordMinimal, domain),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUltradnsRecordExists("ultradns_record.it", &record),
+					resource.TestCheckResourceAttr("ultradns_record.it", "rdata.3994963683", "10.5.0.1"),
+				),
+			},
+			{
+				Config: testCfgMockProvider + fmt.Sprintf(testCfgRecordUpdated, domain),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUltradnsRecordExists("ultradns_record.it", &record),
+					resource.TestCheckResourceAttr("ultradns_record.it", "rdata.1998004057", "10.5.0.2"),
+				),
+			},
+			{
+				// An absolute name and a lower-case type import as the
+				// ID create made
+				Config:                  testCfgMockProvider + fmt.Sprintf(testCfgRecordUpdated, domain),
+				ResourceName:            "ultradns_record.it",
+				ImportState:             true,
+				ImportStateId:           "test-record.example.com.:example.com:a",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: importRecordIgnore,
+			},
+		},
+	})
+}
+
+func TestResourceUltraDNSRecordStateUpgradeV0(t *testing.T) {
+	v0 := map[string]interface{}{
+		"id":   "test-record.ultradns.phinze.com",
+		"name": "test-record",
+		"zone": "ultradns.phinze.com",
+		"type": "A",
+	}
+	v1, err := resourceUltraDNSRecordStateUpgradeV0(v0, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v1["id"] != "test-record:ultradns.phinze.com:A" {
+		t.Errorf("id = %v", v1["id"])
+	}
+}
+
+func TestResourceUltraDNSRecord_unchangedApexNS(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	// An apex NS record managed before manage_system_records existed
+	state := &terraform.InstanceState{
+		ID: "@:example.com:NS",
+		Attributes: map[string]string{
+			"id":   "@:example.com:NS",
+			"zone": "example.com", "name": "@", "type": "NS", "ttl": "3600",
+			"rdata.#": "1",
+			"rdata." + strconv.Itoa(hashRdataString("ns1.example.net.")): "ns1.example.net.",
+		},
+	}
+	cfg := func(rdata ...interface{}) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"zone": "example.com", "name": "@", "type": "NS", "ttl": "3600", "rdata": rdata,
+		})
+	}
+
+	if _, err := resourceUltradnsRecord().Diff(state, cfg("ns1.example.net."), client); err != nil {
+		t.Errorf("unchanged: %v", err)
+	}
+	if _, err := resourceUltradnsRecord().Diff(state, cfg("ns2.example.net."), client); err == nil {
+		t.Error("changed rdata: expected manage_system_records to be required")
+	}
+	if _, err := resourceUltradnsRecord().Diff(nil, cfg("ns1.example.net."), client); err == nil {
+		t.Error("create: expected manage_system_records to be required")
+	}
+}
+
+func TestCheckSystemRecord(t *testing.T) {
+	cases := []struct {
+		name, rrtype        string
+		manageSystemRecords bool
+		manageApexNS        bool
+		err                 bool
+	}{
+		{"www", "A", false, false, false},
+		{"@", "SOA", false, false, true},
+		{"@", "SOA", false, true, true},
+		{"@", "SOA", true, false, false},
+		{"example.com.", "NS", false, false, true},
+		{"example.com.", "NS", false, true, false},
+		{"example.com.", "NS", true, false, false},
+		{"child", "NS", false, false, false},
+	}
+
+	for _, c := range cases {
+		r := rRSetResource{OwnerName: c.name, RRType: c.rrtype, Zone: "example.com"}
+		err := checkSystemRecord(r, c.manageSystemRecords, c.manageApexNS)
+		if (err != nil) != c.err {
+			t.Errorf("checkSystemRecord(%s %s, %v, %v) = %v, want error: %v", c.name, c.rrtype, c.manageSystemRecords, c.manageApexNS, err, c.err)
+		}
+	}
+}
+
+func TestMakeRdataInfos(t *testing.T) {
+	poolType, infos, err := makeRdataInfos([]string{"192.0.2.1"}, nil)
+	if err != nil || poolType != "" || len(infos) != 1 || infos[0]["rdata"] != "192.0.2.1" {
+		t.Errorf("plain record: got %q, %v, %v", poolType, infos, err)
+	}
+
+	tc := udnssdk.RawProfile{
+		"@context": string(udnssdk.TCPoolSchema),
+		"rdataInfo": []interface{}{
+			map[string]interface{}{"state": "NORMAL", "weight": 2, "availableToServe": true},
+			map[string]interface{}{"state": "INACTIVE", "weight": 4},
+		},
+	}
+	poolType, infos, err = makeRdataInfos([]string{"192.0.2.1", "192.0.2.2"}, tc)
+	if err != nil || poolType != "Traffic Controller pool" || len(infos) != 2 {
+		t.Fatalf("tcpool: got %q, %v, %v", poolType, infos, err)
+	}
+	if infos[0]["available_to_serve"] != true || infos[1]["state"] != "INACTIVE" || infos[1]["weight"] != 4 {
+		t.Errorf("tcpool: got %v", infos)
+	}
+
+	dir := udnssdk.RawProfile{
+		"@context": string(udnssdk.DirPoolSchema),
+		"rdataInfo": []interface{}{
+			map[string]interface{}{"geoInfo": map[string]interface{}{"name": "europe"}},
+		},
+	}
+	poolType, infos, err = makeRdataInfos([]string{"192.0.2.1"}, dir)
+	if err != nil || poolType != "Directional pool" || infos[0]["group"] != "europe" {
+		t.Errorf("dirpool: got %q, %v, %v", poolType, infos, err)
+	}
+}
+
+func TestParseRecordID(t *testing.T) {
+	name, zone, typ, err := parseRecordID("www:example.com:CNAME")
+	if err != nil || name != "www" || zone != "example.com" || typ != "CNAME" {
+		t.Errorf("got %q, %q, %q, %v", name, zone, typ, err)
+	}
+	for id, want := range map[string]string{
+		"_dmarc:example.com:TXT":              "_dmarc",
+		"_acme-challenge.www:example.com:TXT": "_acme-challenge.www",
+		"*:example.com:A":                     "*",
+		"%2A.dev:example.com:A":               "*.dev",
+		"odd%3Aname%25:example.com:TXT":       "odd:name%",
+	} {
+		name, _, _, err := parseRecordID(id)
+		if err != nil || name != want {
+			t.Errorf("parseRecordID(%q) name = %q, %v, want %q", id, name, err, want)
+		}
+	}
+	r := rRSetResource{OwnerName: "odd:name%", Zone: "example.com", RRType: "TXT"}
+	if name, _, _, err := parseRecordID(recordID(r)); err != nil || name != r.OwnerName {
+		t.Errorf("recordID(%+v) = %q does not round-trip: %q, %v", r, recordID(r), name, err)
+	}
+	for _, id := range []string{"www.example.com", "www:example.com", ":example.com:A", "a:b:c:d", "bad%zz:example.com:A"} {
+		if _, _, _, err := parseRecordID(id); err == nil {
+			t.Errorf("parseRecordID(%q): expected an error", id)
+		}
+	}
+}
+
+func TestResourceUltraDNSRecord_ignoreTTLDrift(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	r := resourceUltradnsRecord()
+	key := udnssdk.RRSetKey{Zone: "example.com", Name: "www", Type: "A"}
+	cfg := func(ttl string, rdata string) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"zone": "example.com", "name": "www", "type": "A", "ttl": ttl,
+			"rdata": []interface{}{rdata}, "ignore_ttl_drift": true,
+		})
+	}
+	apply := func(state *terraform.InstanceState, c *terraform.ResourceConfig) *terraform.InstanceState {
+		diff, err := r.Diff(state, c, client)
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		state, err = r.Apply(state, diff, client)
+		if err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+		return state
+	}
+	liveTTL := func() int {
+		rrsets, err := client.RRSets.Select(key)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		return rrsets[0].TTL
+	}
+
+	state := apply(nil, cfg("300", "192.0.2.1"))
+
+	// An emergency change made outside Terraform
+	_, err = client.RRSets.Update(key, udnssdk.RRSet{OwnerName: "www", RRType: "A", TTL: 30, RData: []string{"192.0.2.1"}})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	state, err = r.Refresh(state, client)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if state.Attributes["ttl"] != "300" || state.Attributes["current_ttl"] != "30" {
+		t.Errorf("after refresh: ttl = %s, current_ttl = %s", state.Attributes["ttl"], state.Attributes["current_ttl"])
+	}
+	diff, err := r.Diff(state, cfg("300", "192.0.2.1"), client)
+	if err != nil || !diff.Empty() {
+		t.Errorf("after refresh: expected no diff, got %v, %v", diff, err)
+	}
+
+	// Other changes keep the TTL set outside Terraform
+	state = apply(state, cfg("300", "192.0.2.2"))
+	if ttl := liveTTL(); ttl != 30 {
+		t.Errorf("after an rdata change: TTL = %d, want 30", ttl)
+	}
+
+	// A change of ttl itself is applied
+	apply(state, cfg("600", "192.0.2.2"))
+	if ttl := liveTTL(); ttl != 600 {
+		t.Errorf("after a ttl change: TTL = %d, want 600", ttl)
+	}
+
+	// Without ignore_ttl_drift the change shows up as drift
+	c := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"zone": "example.com", "name": "www", "type": "A", "ttl": "600", "rdata": []interface{}{"192.0.2.2"},
+	})
+	state = apply(state, c)
+	_, err = client.RRSets.Update(key, udnssdk.RRSet{OwnerName: "www", RRType: "A", TTL: 30, RData: []string{"192.0.2.2"}})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	state, err = r.Refresh(state, client)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if state.Attributes["ttl"] != "30" {
+		t.Errorf("after refresh without ignore_ttl_drift: ttl = %s, want 30", state.Attributes["ttl"])
+	}
+}
+
+func TestResourceUltraDNSRecord_overlappingRdata(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	for _, rdata := range [][]interface{}{
+		{"2001:db8::1", "2001:DB8:0::1"},
+		{"1.2.3.4", "1.2.3.4 "},
+		{"1.2.3.4", "01.2.3.4"},
+	} {
+		typ := "A"
+		if strings.Contains(rdata[0].(string), ":") {
+			typ = "AAAA"
+		}
+		c := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"zone": "example.com", "name": "www", "type": typ, "rdata": rdata,
+		})
+		if _, err := resourceUltradnsRecord().Diff(nil, c, client); err == nil || !strings.Contains(err.Error(), "same value") {
+			t.Errorf("Diff of %q: got %v, want the overlap refused", rdata, err)
+		}
+	}
+	ws, _ := resourceUltradnsRecord().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"zone": "example.com", "name": "www", "type": "A", "rdata": []interface{}{"1.2.3.4", "01.2.3.4"},
+	}))
+	if len(ws) != 1 {
+		t.Errorf("Validate: got warnings %q, want one for 01.2.3.4", ws)
+	}
+}
+
+func TestResourceUltraDNSRecord_validateSPF(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	for _, validate := range []bool{false, true} {
+		c := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"zone": "example.com", "name": "@", "type": "TXT", "rdata": []interface{}{"v=spf1 ip4:not-an-ip -all"},
+			"validate_spf": validate,
+		})
+		_, err := resourceUltradnsRecord().Diff(nil, c, client)
+		if refused := err != nil && strings.Contains(err.Error(), "malformed SPF policy"); refused != validate {
+			t.Errorf("validate_spf = %v: Diff: %v", validate, err)
+		}
+	}
+}
+
+func TestResourceUltraDNSRecord_createPTR(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	r := resourceUltradnsRecord()
+	cfg := func(createPTR bool, rdata ...interface{}) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"zone": "example.com", "name": "www", "type": "A", "ttl": "300",
+			"rdata": rdata, "create_ptr": createPTR,
+		})
+	}
+	apply := func(state *terraform.InstanceState, c *terraform.ResourceConfig) *terraform.InstanceState {
+		diff, err := r.Diff(state, c, client)
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		if diff == nil {
+			return state
+		}
+		state, err = r.Apply(state, diff, client)
+		if err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+		return state
+	}
+	ptr := func(zone, name string) []string {
+		rrsets, err := client.RRSets.Select(udnssdk.RRSetKey{Zone: zone, Type: "PTR", Name: name})
+		if isRRSetNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		return rrsets[0].RData
+	}
+
+	state := apply(nil, cfg(true, "192.0.2.1", "198.51.100.7"))
+	if got := ptr("2.0.192.in-addr.arpa", "1"); len(got) != 1 || got[0] != "www.example.com." {
+		t.Errorf("after create: PTR 1.2.0.192.in-addr.arpa = %v", got)
+	}
+	if got := ptr("100.51.198.in-addr.arpa", "7"); len(got) != 1 || got[0] != "www.example.com." {
+		t.Errorf("after create: PTR 7.100.51.198.in-addr.arpa = %v", got)
+	}
+	if state.Attributes["ptr.#"] != "2" {
+		t.Errorf("after create: ptr = %v", state.Attributes)
+	}
+
+	// A PTR deleted outside Terraform is recreated
+	if _, err := client.RRSets.Delete(udnssdk.RRSetKey{Zone: "2.0.192.in-addr.arpa", Type: "PTR", Name: "1"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	state, err = r.Refresh(state, client)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	state = apply(state, cfg(true, "192.0.2.1", "198.51.100.7"))
+	if got := ptr("2.0.192.in-addr.arpa", "1"); len(got) != 1 {
+		t.Errorf("after refresh: PTR 1.2.0.192.in-addr.arpa = %v", got)
+	}
+
+	// Removing an address removes its PTR
+	state = apply(state, cfg(true, "192.0.2.1"))
+	if got := ptr("100.51.198.in-addr.arpa", "7"); got != nil {
+		t.Errorf("after removing the address: PTR 7.100.51.198.in-addr.arpa = %v", got)
+	}
+
+	// A PTR that points elsewhere is not taken over
+	_, err = client.RRSets.Create(udnssdk.RRSetKey{Zone: "2.0.192.in-addr.arpa", Type: "PTR", Name: "9"},
+		udnssdk.RRSet{OwnerName: "9", RRType: "PTR", TTL: 300, RData: []string{"other.example.com."}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	diff, err := r.Diff(state, cfg(true, "192.0.2.1", "192.0.2.9"), client)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if _, err := r.Apply(state, diff, client); err == nil || !strings.Contains(err.Error(), "other.example.com.") {
+		t.Errorf("taking over a PTR: got %v", err)
+	}
+
+	// Turning create_ptr off removes the PTRs
+	state = apply(state, cfg(false, "192.0.2.1"))
+	if got := ptr("2.0.192.in-addr.arpa", "1"); got != nil {
+		t.Errorf("after create_ptr = false: PTR 1.2.0.192.in-addr.arpa = %v", got)
+	}
+	if state.Attributes["ptr.#"] != "0" {
+		t.Errorf("after create_ptr = false: ptr = %v", state.Attributes)
+	}
+
+	if _, err := r.Diff(nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"zone": "example.com", "name": "www", "type": "CNAME", "rdata": []interface{}{"web.example.com."}, "create_ptr": true,
+	}), client); err == nil {
+		t.Errorf("create_ptr on a CNAME: expected an error")
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	cases := map[string]string{
+		"192.0.2.1":   "1.2.0.192.in-addr.arpa.",
+		"2001:db8::1": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+	}
+	for ip, want := range cases {
+		if got := reverseName(net.ParseIP(ip)); got != want {
+			t.Errorf("reverseName(%s) = %s, want %s", ip, got, want)
+		}
+	}
+}
+
 func testAccRecordCheckDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*udnssdk.Client)
+	client := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "ultradns_record" {
@@ -100,6 +551,14 @@ func testAccRecordCheckDestroy(s *terraform.State) error {
 	return nil
 }
 
+const testCfgMockProvider = `
+provider "ultradns" {
+  mock     = true
+  username = "TestUltradnsRecord_mock"
+  password = "mock"
+}
+`
+
 const testCfgRecordMinimal = `
 resource "ultradns_record" "it" {
   zone = "%s"
@@ -137,3 +596,23 @@ resource "ultradns_record" "it" {
   ttl   = 3600
 }
 `
+
+const testCfgRecordApexNS = `
+resource "ultradns_record" "it" {
+  zone = "%s"
+  name = "%s."
+
+  rdata = ["ns1.example.net."]
+  type  = "NS"
+}
+`
+
+const testCfgRecordNSDelegation = `
+resource "ultradns_record" "it" {
+  zone = "%s"
+  name = "test-delegation"
+
+  rdata = ["ns1.example.net.", "ns2.example.net."]
+  type  = "NS"
+}
+`
@@ -5,8 +5,9 @@ import (
 	"log"
 	"strings"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terra-farm/udnssdk"
 )
 
 func resourceUltradnsTcpool() *schema.Resource {
@@ -24,9 +25,10 @@ func resourceUltradnsTcpool() *schema.Resource {
 				ForceNew: true,
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressOwnerNameDiff,
 			},
 			"description": {
 				Type:     schema.TypeString,
@@ -67,6 +69,14 @@ func resourceUltradnsTcpool() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "NORMAL",
+							ValidateFunc: validation.StringInSlice([]string{
+								"NORMAL",
+								"ACTIVE",
+								"INACTIVE",
+							}, true),
+							StateFunc: func(v interface{}) string {
+								return strings.ToUpper(v.(string))
+							},
 						},
 						"threshold": {
 							Type:     schema.TypeInt,
@@ -74,19 +84,20 @@ func resourceUltradnsTcpool() *schema.Resource {
 							Default:  1,
 						},
 						"weight": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Default:  2,
-							// Valid: i%2 == 0 && 2 <= i <= 100
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      2,
+							ValidateFunc: validateTcpoolWeight,
 						},
 					},
 				},
 			},
 			// Optional
 			"ttl": {
-				Type:     schema.TypeInt,
-				Optional: true,
-				Default:  3600,
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntBetween(0, maxTTL),
 			},
 			"run_probes": {
 				Type:     schema.TypeBool,
@@ -114,6 +125,11 @@ func resourceUltradnsTcpool() *schema.Resource {
 				// Valid: 0-30
 				// Units: Minutes
 			},
+			"replace_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 			// Computed
 			"hostname": {
 				Type:     schema.TypeString,
@@ -126,7 +142,7 @@ func resourceUltradnsTcpool() *schema.Resource {
 // CRUD Operations
 
 func resourceUltradnsTcpoolCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResourceFromTcpool(d)
 	if err != nil {
@@ -134,7 +150,7 @@ func resourceUltradnsTcpoolCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	log.Printf("[INFO] ultradns_tcpool create: %#v", r)
-	_, err = client.RRSets.Create(r.RRSetKey(), r.RRSet())
+	err = createRRSet(client, r, d.Get("replace_existing").(bool))
 	if err != nil {
 		return fmt.Errorf("create failed: %#v -> %v", r, err)
 	}
@@ -146,7 +162,7 @@ func resourceUltradnsTcpoolCreate(d *schema.ResourceData, meta interface{}) erro
 }
 
 func resourceUltradnsTcpoolRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	rr, err := newRRSetResourceFromTcpool(d)
 	if err != nil {
@@ -155,16 +171,11 @@ func resourceUltradnsTcpoolRead(d *schema.ResourceData, meta interface{}) error
 
 	rrsets, err := client.RRSets.Select(rr.RRSetKey())
 	if err != nil {
-		uderr, ok := err.(*udnssdk.ErrorResponseList)
-		if ok {
-			for _, resps := range uderr.Responses {
-				// 70002 means Records Not Found
-				if resps.ErrorCode == 70002 {
-					d.SetId("")
-					return nil
-				}
-				return fmt.Errorf("resource not found: %v", err)
-			}
+		// Deleted outside Terraform, possibly along with its whole zone
+		if isRRSetNotFound(err) || isZoneNotFound(err) {
+			log.Printf("[INFO] ultradns_tcpool %s is gone, removing it from state: %v", d.Id(), err)
+			d.SetId("")
+			return nil
 		}
 		return fmt.Errorf("resource not found: %v", err)
 	}
@@ -213,7 +224,7 @@ func resourceUltradnsTcpoolRead(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceUltradnsTcpoolUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResourceFromTcpool(d)
 	if err != nil {
@@ -230,7 +241,7 @@ func resourceUltradnsTcpoolUpdate(d *schema.ResourceData, meta interface{}) erro
 }
 
 func resourceUltradnsTcpoolDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*udnssdk.Client)
+	client := meta.(*Client)
 
 	r, err := newRRSetResourceFromTcpool(d)
 	if err != nil {
@@ -239,7 +250,7 @@ func resourceUltradnsTcpoolDelete(d *schema.ResourceData, meta interface{}) erro
 
 	log.Printf("[INFO] ultradns_tcpool delete: %+v", r)
 	_, err = client.RRSets.Delete(r.RRSetKey())
-	if err != nil {
+	if err != nil && !isRRSetNotFound(err) && !isZoneNotFound(err) {
 		return fmt.Errorf("resource delete failed: %v", err)
 	}
 
@@ -255,7 +266,7 @@ func newRRSetResourceFromTcpool(d *schema.ResourceData) (rRSetResource, error) {
 		// per https://portal.ultradns.com/static/docs/REST-API_User_Guide.pdf
 		RRType:    "A",
 		Zone:      d.Get("zone").(string),
-		OwnerName: d.Get("name").(string),
+		OwnerName: normalizeOwnerName(d.Get("name").(string), d.Get("zone").(string)),
 		TTL:       d.Get("ttl").(int),
 		RData:     unzipRdataHosts(rDataRaw),
 	}
@@ -292,7 +303,7 @@ func unzipRdataInfos(configured []interface{}) []udnssdk.SBRDataInfo {
 			FailoverDelay: data["failover_delay"].(int),
 			Priority:      data["priority"].(int),
 			RunProbes:     data["run_probes"].(bool),
-			State:         data["state"].(string),
+			State:         strings.ToUpper(data["state"].(string)),
 			Threshold:     data["threshold"].(int),
 			Weight:        data["weight"].(int),
 		}
@@ -301,6 +312,16 @@ func unzipRdataInfos(configured []interface{}) []udnssdk.SBRDataInfo {
 	return rdataInfos
 }
 
+// validateTcpoolWeight checks that a weight is an even number from 2 to
+// 100, which is all the API accepts
+func validateTcpoolWeight(v interface{}, k string) (ws []string, errors []error) {
+	i := v.(int)
+	if i < 2 || i > 100 || i%2 != 0 {
+		errors = append(errors, fmt.Errorf("%s must be an even number from 2 to 100, got: %d", k, i))
+	}
+	return
+}
+
 // collate and zip RData and RDataInfo into []map[string]interface{}
 func zipRData(rds []string, rdis []udnssdk.SBRDataInfo) []map[string]interface{} {
 	result := make([]map[string]interface{}, 0, len(rds))
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/terra-farm/udnssdk"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/terra-farm/udnssdk"
 )
 
 func TestAccUltradnsTcpool(t *testing.T) {
@@ -154,3 +154,16 @@ resource "ultradns_tcpool" "it" {
   backup_record_failover_delay = 30
 }
 `
+
+func TestValidateTcpoolWeight(t *testing.T) {
+	for _, w := range []int{2, 4, 50, 100} {
+		if _, es := validateTcpoolWeight(w, "weight"); len(es) != 0 {
+			t.Errorf("weight %d: unexpected errors %v", w, es)
+		}
+	}
+	for _, w := range []int{0, 1, 3, 99, 102} {
+		if _, es := validateTcpoolWeight(w, "weight"); len(es) != 1 {
+			t.Errorf("weight %d: expected 1 error, got %v", w, es)
+		}
+	}
+}
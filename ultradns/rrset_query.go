@@ -0,0 +1,210 @@
+package ultradns
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+// rRSetQuery describes a search of a zone's RRSets that is filtered
+// server-side, so callers don't have to fetch a whole zone and filter it
+type rRSetQuery struct {
+	Zone string
+	// Type restricts results to a single RRType; "" or "ANY" matches all
+	Type string
+	// Owner matches owner names containing the given string
+	Owner string
+	// TTL matches RRSets with the given TTL; 0 matches all
+	TTL int
+	// Value matches RRSets with an rdata value containing the given string
+	Value string
+	// Kind restricts results to plain records ("RECORDS"), all pools
+	// ("POOLS") or one kind of pool, e.g. "TC_POOLS"; "" matches all
+	Kind string
+}
+
+// RRSetKey returns the key of the RRSet collection searched by q
+func (q rRSetQuery) RRSetKey() udnssdk.RRSetKey {
+	k := udnssdk.RRSetKey{
+		Zone: q.Zone,
+		Type: q.Type,
+	}
+	if k.Type == "" {
+		k.Type = "ANY"
+	}
+	return k
+}
+
+// Filter renders the "q" parameter understood by the RRSets API
+func (q rRSetQuery) Filter() string {
+	fs := []string{}
+	if q.Owner != "" {
+		fs = append(fs, fmt.Sprintf("owner:%s", q.Owner))
+	}
+	if q.TTL != 0 {
+		fs = append(fs, fmt.Sprintf("ttl:%d", q.TTL))
+	}
+	if q.Value != "" {
+		fs = append(fs, fmt.Sprintf("value:%s", q.Value))
+	}
+	if q.Kind != "" {
+		fs = append(fs, fmt.Sprintf("kind:%s", q.Kind))
+	}
+	return strings.Join(fs, " ")
+}
+
+// QueryURI generates the query URI for q at the given offset
+func (q rRSetQuery) QueryURI(offset int) string {
+	v := url.Values{}
+	if f := q.Filter(); f != "" {
+		v.Set("q", f)
+	}
+	v.Set("offset", fmt.Sprintf("%d", offset))
+	return fmt.Sprintf("%s?%s", q.RRSetKey().URI(), v.Encode())
+}
+
+// selectRRSets lists every RRSet matching q, paginating through all
+// available results
+func selectRRSets(client *Client, q rRSetQuery) ([]udnssdk.RRSet, error) {
+	rrsets := []udnssdk.RRSet{}
+	err := eachRRSet(client, q, func(r udnssdk.RRSet) error {
+		rrsets = append(rrsets, r)
+		return nil
+	})
+	return rrsets, err
+}
+
+// eachRRSet calls fn with every RRSet matching q, paginating through all
+// available results. Pages are decoded one RRSet at a time as they are
+// read, so that zone-wide reads only hold the RRSets fn keeps, however
+// large the zone.
+func eachRRSet(client *Client, q rRSetQuery, fn func(udnssdk.RRSet) error) error {
+	maxerrs := 5
+	waittime := 5 * time.Second
+
+	errcnt := 0
+	offset := 0
+
+	for {
+		res, ri, err := decodeRRSetPage(client, q.QueryURI(offset), fn)
+		if err != nil {
+			// Only an error status is retried, as none of its RRSets has
+			// been passed to fn yet
+			if res != nil && res.StatusCode >= 500 {
+				errcnt = errcnt + 1
+				if errcnt < maxerrs {
+					time.Sleep(waittime)
+					continue
+				}
+			}
+			return err
+		}
+
+		log.Printf("[DEBUG] selectRRSets(%q) ResultInfo: %+v", q.Filter(), ri)
+		if ri.ReturnedCount == 0 || ri.ReturnedCount+ri.Offset >= ri.TotalCount {
+			return nil
+		}
+		offset = ri.ReturnedCount + ri.Offset
+	}
+}
+
+// decodeRRSetPage GETs one page of an RRSets listing from uri, passing
+// each of its RRSets to fn as it is decoded, and returns its ResultInfo.
+// It stands in for udnssdk.Client.Do, which decodes the whole page into
+// an RRSetListDTO first.
+func decodeRRSetPage(client *Client, uri string, fn func(udnssdk.RRSet) error) (*http.Response, udnssdk.ResultInfo, error) {
+	var ri udnssdk.ResultInfo
+	req, err := client.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, ri, err
+	}
+	res, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, ri, err
+	}
+	defer res.Body.Close()
+
+	err = udnssdk.CheckResponse(res)
+	if err != nil {
+		return res, ri, err
+	}
+
+	// Errors of fn are returned as they are
+	var fnErr error
+	each := func(r udnssdk.RRSet) error {
+		fnErr = fn(r)
+		return fnErr
+	}
+	dec := json.NewDecoder(res.Body)
+	malformed := func(err error) error {
+		if fnErr != nil {
+			return fnErr
+		}
+		return fmt.Errorf("GET %s: malformed RRSets listing: %v", req.URL, err)
+	}
+	if err := expectJSONDelim(dec, '{'); err != nil {
+		return res, ri, malformed(err)
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return res, ri, malformed(err)
+		}
+		switch key, _ := t.(string); {
+		case strings.EqualFold(key, "resultInfo"):
+			err = dec.Decode(&ri)
+		case strings.EqualFold(key, "rrSets"):
+			err = decodeRRSetArray(dec, each)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return res, ri, malformed(err)
+		}
+	}
+	if err := expectJSONDelim(dec, '}'); err != nil {
+		return res, ri, malformed(err)
+	}
+	return res, ri, nil
+}
+
+// decodeRRSetArray passes each element of the JSON array next in dec to
+// fn; a null array has none
+func decodeRRSetArray(dec *json.Decoder, fn func(udnssdk.RRSet) error) error {
+	t, err := dec.Token()
+	if err != nil || t == nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("expected an array, got %v", t)
+	}
+	for dec.More() {
+		var r udnssdk.RRSet
+		if err := dec.Decode(&r); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(dec, ']')
+}
+
+// expectJSONDelim reads the next token of dec, which must be delim
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %v, got %v", delim, t)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package ultradns
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+func TestRRSetQuery_QueryURI(t *testing.T) {
+	cases := []struct {
+		q      rRSetQuery
+		offset int
+		want   string
+	}{
+		{
+			q:    rRSetQuery{Zone: "example.com."},
+			want: "zones/example.com./rrsets/ANY?offset=0",
+		},
+		{
+			q:      rRSetQuery{Zone: "example.com.", Type: "CNAME"},
+			offset: 100,
+			want:   "zones/example.com./rrsets/CNAME?offset=100",
+		},
+		{
+			q:    rRSetQuery{Zone: "example.com.", Type: "TXT", Owner: "_dmarc"},
+			want: "zones/example.com./rrsets/TXT?offset=0&q=owner%3A_dmarc",
+		},
+		{
+			q:    rRSetQuery{Zone: "example.com.", Type: "A", Owner: "www", TTL: 300, Value: "192.0.2.1", Kind: "RECORDS"},
+			want: "zones/example.com./rrsets/A?offset=0&q=owner%3Awww+ttl%3A300+value%3A192.0.2.1+kind%3ARECORDS",
+		},
+	}
+
+	for _, c := range cases {
+		if got := c.q.QueryURI(c.offset); got != c.want {
+			t.Errorf("%#v.QueryURI(%d) = %q, want %q", c.q, c.offset, got, c.want)
+		}
+	}
+}
+
+func TestEachRRSet(t *testing.T) {
+	pages := map[string]string{
+		"0": `{"zoneName": "example.com.", "rrSets": [
+			{"ownerName": "a.example.com.", "rrtype": "A (1)", "ttl": 300, "rdata": ["192.0.2.1"]},
+			{"ownerName": "b.example.com.", "rrtype": "A (1)", "ttl": 300, "rdata": ["192.0.2.2"]}
+		], "queryInfo": {"sort": "OWNER", "reverse": false, "limit": 2}, "resultInfo": {"totalCount": 3, "offset": 0, "returnedCount": 2}}`,
+		"2": `{"resultInfo": {"totalCount": 3, "offset": 2, "returnedCount": 1}, "rrSets": [
+			{"ownerName": "c.example.com.", "rrtype": "A (1)", "ttl": 300, "rdata": ["192.0.2.3"]}
+		]}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, pages[r.URL.Query().Get("offset")])
+	}))
+	defer ts.Close()
+	client, err := (&Config{AccessToken: "a1", BaseURL: ts.URL}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+
+	rrsets, err := selectRRSets(client, rRSetQuery{Zone: "example.com", Type: "A"})
+	if err != nil {
+		t.Fatalf("selectRRSets: %v", err)
+	}
+	owners := []string{}
+	for _, r := range rrsets {
+		owners = append(owners, r.OwnerName)
+	}
+	if want := "[a.example.com. b.example.com. c.example.com.]"; fmt.Sprint(owners) != want {
+		t.Errorf("owners = %v, want %v", owners, want)
+	}
+
+	// An error of fn stops the listing and is returned as is
+	stop := errors.New("stop")
+	calls := 0
+	err = eachRRSet(client, rRSetQuery{Zone: "example.com", Type: "A"}, func(r udnssdk.RRSet) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("eachRRSet with a failing fn: got %v after %d calls", err, calls)
+	}
+
+	pages["0"] = `{"rrSets": [{"ownerName": "a.example.com."}`
+	if _, err := selectRRSets(client, rRSetQuery{Zone: "example.com", Type: "A"}); err == nil {
+		t.Error("truncated listing: expected an error")
+	}
+}
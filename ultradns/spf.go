@@ -0,0 +1,380 @@
+package ultradns
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// spfLookupLimit is the RFC 7208 limit on terms that cause DNS lookups
+// during a single SPF evaluation
+const spfLookupLimit = 10
+
+// isSPFPolicy reports whether a TXT value is an SPF policy
+func isSPFPolicy(txt string) bool {
+	v := strings.ToLower(txt)
+	return v == "v=spf1" || strings.HasPrefix(v, "v=spf1 ")
+}
+
+// parseSPF checks the syntax of an SPF policy and returns the number of
+// terms in it that cause DNS lookups. Nested include: and redirect=
+// policies are not fetched, so the count is a lower bound.
+func parseSPF(policy string) (int, error) {
+	terms := strings.Fields(policy)
+	if len(terms) == 0 || !strings.EqualFold(terms[0], "v=spf1") {
+		return 0, fmt.Errorf("SPF policy must begin with \"v=spf1\"")
+	}
+
+	lookups := 0
+	modifiers := map[string]bool{}
+	for _, term := range terms[1:] {
+		// Modifiers are name=value and may appear at most once
+		if i := strings.Index(term, "="); i > 0 && !strings.ContainsAny(term[:i], ":/") {
+			name := strings.ToLower(term[:i])
+			value := term[i+1:]
+			if (name == "redirect" || name == "exp") && modifiers[name] {
+				return lookups, fmt.Errorf("SPF modifier %q appears more than once", name)
+			}
+			modifiers[name] = true
+			switch name {
+			case "redirect":
+				if value == "" {
+					return lookups, fmt.Errorf("SPF modifier %q requires a domain", term)
+				}
+				lookups++
+			case "exp":
+				if value == "" {
+					return lookups, fmt.Errorf("SPF modifier %q requires a domain", term)
+				}
+			}
+			continue
+		}
+
+		mech := strings.TrimLeft(term, "+-~?")
+		if len(term)-len(mech) > 1 {
+			return lookups, fmt.Errorf("SPF term %q has more than one qualifier", term)
+		}
+		name, arg := mech, ""
+		if i := strings.IndexAny(mech, ":/"); i >= 0 {
+			name, arg = mech[:i], mech[i:]
+		}
+
+		switch strings.ToLower(name) {
+		case "all":
+			if arg != "" {
+				return lookups, fmt.Errorf("SPF mechanism %q takes no argument", term)
+			}
+		case "include", "exists":
+			if !strings.HasPrefix(arg, ":") || len(arg) == 1 {
+				return lookups, fmt.Errorf("SPF mechanism %q requires a domain", term)
+			}
+			lookups++
+		case "a", "mx":
+			if err := checkSPFDualCIDR(term, arg); err != nil {
+				return lookups, err
+			}
+			lookups++
+		case "ptr":
+			if arg != "" && (!strings.HasPrefix(arg, ":") || len(arg) == 1) {
+				return lookups, fmt.Errorf("SPF mechanism %q has an invalid domain", term)
+			}
+			lookups++
+		case "ip4", "ip6":
+			if err := checkSPFIP(term, strings.ToLower(name), arg); err != nil {
+				return lookups, err
+			}
+		default:
+			return lookups, fmt.Errorf("SPF term %q is not a known mechanism", term)
+		}
+	}
+	return lookups, nil
+}
+
+// checkSPFIP validates the ":address[/prefix]" argument of ip4/ip6
+func checkSPFIP(term, name, arg string) error {
+	if !strings.HasPrefix(arg, ":") {
+		return fmt.Errorf("SPF mechanism %q requires an address", term)
+	}
+	addr, prefix := arg[1:], ""
+	if i := strings.Index(addr, "/"); i >= 0 {
+		addr, prefix = addr[:i], addr[i+1:]
+	}
+	ip := net.ParseIP(addr)
+	max := 32
+	if name == "ip6" {
+		max = 128
+	}
+	if ip == nil || (name == "ip4") != (ip.To4() != nil) {
+		return fmt.Errorf("SPF mechanism %q has an invalid %s address", term, name)
+	}
+	if !validSPFPrefix(prefix, max) || strings.HasSuffix(arg, "/") {
+		return fmt.Errorf("SPF mechanism %q has an invalid prefix length", term)
+	}
+	return nil
+}
+
+// checkSPFDualCIDR validates the "[:domain][/ip4-cidr][//ip6-cidr]"
+// argument of the a and mx mechanisms
+func checkSPFDualCIDR(term, arg string) error {
+	if strings.HasPrefix(arg, ":") {
+		i := strings.Index(arg, "/")
+		if i < 0 {
+			i = len(arg)
+		}
+		if i == 1 {
+			return fmt.Errorf("SPF mechanism %q has an empty domain", term)
+		}
+		arg = arg[i:]
+	}
+
+	ip4, ip6 := "", ""
+	switch {
+	case arg == "":
+		return nil
+	case strings.HasPrefix(arg, "//"):
+		ip6 = arg[2:]
+	default:
+		parts := strings.SplitN(arg[1:], "//", 2)
+		ip4 = parts[0]
+		if len(parts) == 2 {
+			ip6 = parts[1]
+		}
+		if ip4 == "" || (len(parts) == 2 && ip6 == "") {
+			return fmt.Errorf("SPF mechanism %q has an invalid prefix length", term)
+		}
+	}
+
+	if !validSPFPrefix(ip4, 32) || !validSPFPrefix(ip6, 128) {
+		return fmt.Errorf("SPF mechanism %q has an invalid prefix length", term)
+	}
+	return nil
+}
+
+// validSPFPrefix reports whether p is empty or a prefix length <= max
+func validSPFPrefix(p string, max int) bool {
+	if p == "" {
+		return true
+	}
+	n, err := strconv.Atoi(p)
+	return err == nil && n >= 0 && n <= max
+}
+
+// isTXTType reports whether rrtype is one whose rdata may be an SPF
+// policy: TXT, or the obsolete SPF type
+func isTXTType(rrtype string) bool {
+	return strings.EqualFold(rrtype, "TXT") || strings.EqualFold(rrtype, "SPF")
+}
+
+// checkSPFRdata parses the rdata values that are SPF policies. Resources
+// with validate_spf refuse the plan with its error.
+func checkSPFRdata(rdata []string) error {
+	for _, v := range rdata {
+		// A policy split into several strings is evaluated joined, RFC 7208 3.3
+		value := strings.Join(splitTXTStrings(normalizeTXTRdata(v)), "")
+		if !isSPFPolicy(value) {
+			continue
+		}
+		lookups, err := parseSPF(value)
+		if err != nil {
+			return fmt.Errorf("malformed SPF policy %q: %v", v, err)
+		}
+		if lookups > spfLookupLimit {
+			return fmt.Errorf("SPF policy %q needs at least %d DNS lookups, more than the limit of %d", v, lookups, spfLookupLimit)
+		}
+	}
+	return nil
+}
+
+// spfFlattener rewrites the include: mechanisms of an SPF policy as the
+// ip4: and ip6: mechanisms they resolve to, so that the policy needs no
+// DNS lookups for them at evaluation time
+type spfFlattener struct {
+	lookupTXT func(string) ([]string, error)
+	lookupIP  func(string) ([]net.IP, error)
+	lookupMX  func(string) ([]string, error)
+
+	// budget is the most DNS lookups flattening may make
+	budget int
+	used   int
+}
+
+// spfFlattenMaxDepth bounds the nesting of include: and redirect=
+const spfFlattenMaxDepth = 10
+
+// flatten returns policy with its include: mechanisms flattened. Other
+// terms of policy are kept as they are.
+func (f *spfFlattener) flatten(policy string) (string, error) {
+	if _, err := parseSPF(policy); err != nil {
+		return "", err
+	}
+
+	terms := []string{"v=spf1"}
+	seen := map[string]bool{}
+	for _, term := range strings.Fields(policy)[1:] {
+		mech := strings.TrimLeft(term, "+-~?")
+		qualifier := strings.TrimPrefix(term[:len(term)-len(mech)], "+")
+		if !strings.HasPrefix(strings.ToLower(mech), "include:") {
+			terms = append(terms, term)
+			continue
+		}
+
+		ips, err := f.resolve(mech[len("include:"):], 1)
+		if err != nil {
+			return "", fmt.Errorf("flattening %q: %v", term, err)
+		}
+		for _, ip := range ips {
+			if !seen[qualifier+ip] {
+				seen[qualifier+ip] = true
+				terms = append(terms, qualifier+ip)
+			}
+		}
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// resolve returns the ip4: and ip6: mechanisms that the SPF policy of
+// domain passes. Policies whose result can't be expressed that way, such
+// as ones that fail particular addresses or use macros, are an error.
+func (f *spfFlattener) resolve(domain string, depth int) ([]string, error) {
+	if depth > spfFlattenMaxDepth {
+		return nil, fmt.Errorf("includes are nested more than %d deep", spfFlattenMaxDepth)
+	}
+	if strings.Contains(domain, "%") {
+		return nil, fmt.Errorf("domain %q uses macros, which can't be flattened", domain)
+	}
+	if err := f.spend(); err != nil {
+		return nil, err
+	}
+	txts, err := f.lookupTXT(domain)
+	if err != nil {
+		return nil, fmt.Errorf("TXT lookup of %s failed: %v", domain, err)
+	}
+	policies := []string{}
+	for _, txt := range txts {
+		if isSPFPolicy(txt) {
+			policies = append(policies, txt)
+		}
+	}
+	if len(policies) != 1 {
+		return nil, fmt.Errorf("%s has %d SPF policies, not 1", domain, len(policies))
+	}
+	if _, err := parseSPF(policies[0]); err != nil {
+		return nil, fmt.Errorf("%s: %v", domain, err)
+	}
+
+	ips := []string{}
+	for _, term := range strings.Fields(policies[0])[1:] {
+		if i := strings.Index(term, "="); i > 0 && !strings.ContainsAny(term[:i], ":/") {
+			switch strings.ToLower(term[:i]) {
+			case "redirect":
+				more, err := f.resolve(term[i+1:], depth+1)
+				if err != nil {
+					return nil, err
+				}
+				ips = append(ips, more...)
+			}
+			continue
+		}
+
+		mech := strings.TrimLeft(term, "+-~?")
+		name, arg := mech, ""
+		if i := strings.IndexAny(mech, ":/"); i >= 0 {
+			name, arg = mech[:i], mech[i:]
+		}
+		name = strings.ToLower(name)
+		if name == "all" {
+			continue
+		}
+		if len(term) != len(mech) && term[0] != '+' {
+			return nil, fmt.Errorf("%s: %q doesn't pass, which can't be flattened", domain, term)
+		}
+
+		switch name {
+		case "ip4", "ip6":
+			ips = append(ips, mech)
+		case "include":
+			more, err := f.resolve(arg[1:], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			ips = append(ips, more...)
+		case "a", "mx":
+			host, cidr4, cidr6 := splitSPFDualCIDR(arg, domain)
+			if strings.Contains(host, "%") {
+				return nil, fmt.Errorf("%s: %q uses macros, which can't be flattened", domain, term)
+			}
+			hosts := []string{host}
+			if name == "mx" {
+				if err := f.spend(); err != nil {
+					return nil, err
+				}
+				hosts, err = f.lookupMX(host)
+				if err != nil {
+					return nil, fmt.Errorf("MX lookup of %s failed: %v", host, err)
+				}
+			}
+			for _, h := range hosts {
+				if err := f.spend(); err != nil {
+					return nil, err
+				}
+				addrs, err := f.lookupIP(h)
+				if err != nil {
+					return nil, fmt.Errorf("address lookup of %s failed: %v", h, err)
+				}
+				for _, ip := range addrs {
+					ips = append(ips, spfIPMechanism(ip, cidr4, cidr6))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: %q can't be flattened", domain, term)
+		}
+	}
+	return ips, nil
+}
+
+// spend counts a DNS lookup against the budget
+func (f *spfFlattener) spend() error {
+	if f.used >= f.budget {
+		return fmt.Errorf("flattening needs more than the budget of %d DNS lookups", f.budget)
+	}
+	f.used++
+	return nil
+}
+
+// splitSPFDualCIDR splits the "[:domain][/ip4-cidr][//ip6-cidr]"
+// argument of the a and mx mechanisms, defaulting the domain
+func splitSPFDualCIDR(arg, domain string) (host, cidr4, cidr6 string) {
+	host = domain
+	if strings.HasPrefix(arg, ":") {
+		i := strings.Index(arg, "/")
+		if i < 0 {
+			i = len(arg)
+		}
+		host, arg = arg[1:i], arg[i:]
+	}
+	if strings.HasPrefix(arg, "//") {
+		return host, "", arg[2:]
+	}
+	parts := strings.SplitN(strings.TrimPrefix(arg, "/"), "//", 2)
+	cidr4 = parts[0]
+	if len(parts) == 2 {
+		cidr6 = parts[1]
+	}
+	return host, cidr4, cidr6
+}
+
+// spfIPMechanism renders ip as an ip4: or ip6: mechanism with the
+// matching prefix length, if any
+func spfIPMechanism(ip net.IP, cidr4, cidr6 string) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		if cidr4 != "" {
+			return fmt.Sprintf("ip4:%s/%s", ip4, cidr4)
+		}
+		return fmt.Sprintf("ip4:%s", ip4)
+	}
+	if cidr6 != "" {
+		return fmt.Sprintf("ip6:%s/%s", ip, cidr6)
+	}
+	return fmt.Sprintf("ip6:%s", ip)
+}
@@ -0,0 +1,106 @@
+package ultradns
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseSPF(t *testing.T) {
+	cases := []struct {
+		policy  string
+		lookups int
+		err     bool
+	}{
+		{"v=spf1 -all", 0, false},
+		{"v=spf1 ip4:192.0.2.0/24 ip6:2001:db8::/32 ~all", 0, false},
+		{"v=spf1 a mx:mail.example.com/24//64 include:_spf.example.net -all", 3, false},
+		{"v=spf1 ptr exists:%{i}.example.com redirect=_spf.example.com", 3, false},
+		{"v=spf1 include:_spf.example.net exp=explain.example.com", 1, false},
+		{"v=spf2 -all", 0, true},
+		{"v=spf1 include -all", 0, true},
+		{"v=spf1 ip4:2001:db8::1 -all", 0, true},
+		{"v=spf1 ip4:192.0.2.0/33 -all", 0, true},
+		{"v=spf1 a/ -all", 1, true},
+		{"v=spf1 --all", 0, true},
+		{"v=spf1 bogus:example.com -all", 0, true},
+		{"v=spf1 redirect=a.example.com redirect=b.example.com", 1, true},
+	}
+
+	for _, c := range cases {
+		lookups, err := parseSPF(c.policy)
+		if (err != nil) != c.err {
+			t.Errorf("parseSPF(%q) error = %v, want error: %v", c.policy, err, c.err)
+			continue
+		}
+		if !c.err && lookups != c.lookups {
+			t.Errorf("parseSPF(%q) lookups = %d, want %d", c.policy, lookups, c.lookups)
+		}
+	}
+}
+
+func TestCheckSPFRdata(t *testing.T) {
+	if err := checkSPFRdata([]string{"google-site-verification=abc", "v=spf1 -all"}); err != nil {
+		t.Errorf("valid values: got %v", err)
+	}
+
+	many := "v=spf1" + strings.Repeat(" include:example.com", 11) + " -all"
+	if err := checkSPFRdata([]string{many}); err == nil {
+		t.Error("11 lookups: expected an error")
+	}
+
+	split := `"v=spf1` + strings.Repeat(" include:example.com", 6) + `" "` + strings.Repeat(" include:example.com", 5) + ` -all"`
+	if err := checkSPFRdata([]string{split}); err == nil {
+		t.Error("11 lookups over two strings: expected an error")
+	}
+
+	if err := checkSPFRdata([]string{"v=spf1 ip4:not-an-ip -all"}); err == nil {
+		t.Error("malformed: expected an error")
+	}
+}
+
+func TestSPFFlattener(t *testing.T) {
+	txt := map[string][]string{
+		"_spf.example.net":  {"v=spf1 ip4:192.0.2.0/24 include:_spf2.example.net a mx/28 -all"},
+		"_spf2.example.net": {"google-site-verification=abc", "v=spf1 ip6:2001:db8::/32 ~all"},
+		"_bad.example.net":  {"v=spf1 -ip4:192.0.2.1 +all"},
+		"_loop.example.net": {"v=spf1 include:_loop.example.net"},
+	}
+	f := &spfFlattener{
+		lookupTXT: func(name string) ([]string, error) { return txt[name], nil },
+		lookupIP: func(name string) ([]net.IP, error) {
+			if name == "mail.example.net" {
+				return []net.IP{net.ParseIP("198.51.100.25")}, nil
+			}
+			return []net.IP{net.ParseIP("198.51.100.1"), net.ParseIP("2001:db8::1")}, nil
+		},
+		lookupMX: func(name string) ([]string, error) { return []string{"mail.example.net"}, nil },
+		budget:   10,
+	}
+
+	got, err := f.flatten("v=spf1 mx ~include:_spf.example.net include:_spf2.example.net -all")
+	want := "v=spf1 mx ~ip4:192.0.2.0/24 ~ip6:2001:db8::/32 ~ip4:198.51.100.1 ~ip6:2001:db8::1 ~ip4:198.51.100.25/28 ip6:2001:db8::/32 -all"
+	if err != nil || got != want {
+		t.Errorf("flatten:\n got %q, %v\nwant %q", got, err, want)
+	}
+	if f.used != 6 {
+		t.Errorf("flatten used %d lookups, want 6", f.used)
+	}
+
+	for _, policy := range []string{
+		"v=spf1 include:_bad.example.net -all",
+		"v=spf1 include:_loop.example.net -all",
+		"v=spf1 include:_missing.example.net -all",
+		"v=spf1 include:%{d}.example.net -all",
+	} {
+		f.used = 0
+		if _, err := f.flatten(policy); err == nil {
+			t.Errorf("flatten(%q): expected an error", policy)
+		}
+	}
+
+	f.used, f.budget = 0, 2
+	if _, err := f.flatten("v=spf1 include:_spf.example.net -all"); err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Errorf("over budget: got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package ultradns
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenExpiryMargin is how long before its expiry a cached token stops
+// being handed out, so that it doesn't expire mid-run
+const tokenExpiryMargin = 5 * time.Minute
+
+// fileTokenSource caches the access tokens of base in a file, so that a
+// plan followed by an apply logs in once instead of twice. Tokens are
+// keyed by username and token URL, and the file is only readable by its
+// owner.
+type fileTokenSource struct {
+	path string
+	key  string
+	base oauth2.TokenSource
+
+	mu sync.Mutex
+	// stale is a token the API refused, not to be read from the file
+	stale string
+}
+
+// cachedToken is the part of an oauth2.Token written to the cache file
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+func newFileTokenSource(path, username, tokenURL string, base oauth2.TokenSource) *fileTokenSource {
+	sum := sha256.Sum256([]byte(username + "\x00" + tokenURL))
+	return &fileTokenSource{
+		path: path,
+		key:  hex.EncodeToString(sum[:]),
+		base: base,
+	}
+}
+
+func (s *fileTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens := s.read()
+	if t, ok := tokens[s.key]; ok && t.AccessToken != s.stale && time.Now().Add(tokenExpiryMargin).Before(t.Expiry) {
+		log.Printf("[DEBUG] UltraDNS access token read from %s, expires %s", s.path, t.Expiry)
+		return &oauth2.Token{AccessToken: t.AccessToken, TokenType: t.TokenType, Expiry: t.Expiry}, nil
+	}
+
+	token, err := s.base.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token.Expiry.IsZero() {
+		// A token that never expires is not worth the risk of keeping
+		return token, nil
+	}
+
+	for k, t := range tokens {
+		if time.Now().After(t.Expiry) {
+			delete(tokens, k)
+		}
+	}
+	tokens[s.key] = cachedToken{AccessToken: token.AccessToken, TokenType: token.TokenType, Expiry: token.Expiry}
+	if err := s.write(tokens); err != nil {
+		log.Printf("[WARN] writing the UltraDNS token cache %s failed: %v", s.path, err)
+	}
+	return token, nil
+}
+
+func (s *fileTokenSource) discard(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = accessToken
+}
+
+// read returns the tokens in the cache file, or none if it is missing,
+// malformed or readable by others
+func (s *fileTokenSource) read() map[string]cachedToken {
+	tokens := map[string]cachedToken{}
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return tokens
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&0077 != 0 {
+		log.Printf("[WARN] ignoring the UltraDNS token cache %s: its mode is %v, not 0600", s.path, fi.Mode().Perm())
+		return tokens
+	}
+	b, err := ioutil.ReadFile(s.path)
+	if err == nil {
+		err = json.Unmarshal(b, &tokens)
+	}
+	if err != nil {
+		log.Printf("[WARN] ignoring the UltraDNS token cache %s: %v", s.path, err)
+		return map[string]cachedToken{}
+	}
+	return tokens
+}
+
+// write replaces the cache file atomically, so that concurrent runs
+// never read a partial file
+func (s *fileTokenSource) write(tokens map[string]cachedToken) error {
+	b, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+		f.Close()
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("replacing the token cache: %v", err)
+	}
+	return nil
+}
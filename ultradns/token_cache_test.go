@@ -0,0 +1,56 @@
+package ultradns
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// countingTokenSource hands out a new token, valid for an hour, on every call
+type countingTokenSource struct {
+	calls int
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	s.calls++
+	return &oauth2.Token{AccessToken: "token", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)}, nil
+}
+
+func TestFileTokenSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ultradns-token-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tokens.json")
+
+	base := &countingTokenSource{}
+	for i := 0; i < 2; i++ {
+		// A new source per run, as for a plan followed by an apply
+		token, err := newFileTokenSource(path, "user", "https://api.example.net/v1/authorization/token", base).Token()
+		if err != nil || token.AccessToken != "token" {
+			t.Fatalf("run %d: Token() = %+v, %v", i, token, err)
+		}
+	}
+	if base.calls != 1 {
+		t.Errorf("logged in %d times, want 1", base.calls)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("cache file: %v, %v, want mode 0600", fi, err)
+	}
+
+	// Other credentials don't share the cached token
+	if _, err := newFileTokenSource(path, "other", "https://api.example.net/v1/authorization/token", base).Token(); err != nil || base.calls != 2 {
+		t.Errorf("other user: %v, logged in %d times, want 2", err, base.calls)
+	}
+
+	// Nor does a cache others can read
+	os.Chmod(path, 0644)
+	if _, err := newFileTokenSource(path, "user", "https://api.example.net/v1/authorization/token", base).Token(); err != nil || base.calls != 3 {
+		t.Errorf("readable cache: %v, logged in %d times, want 3", err, base.calls)
+	}
+}
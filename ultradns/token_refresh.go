@@ -0,0 +1,102 @@
+package ultradns
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// refreshingTokenSource hands out one access token until it expires or
+// the API refuses it, then replaces it: with the refresh token when it
+// has one, and otherwise, or if refreshing fails, from base.
+type refreshingTokenSource struct {
+	// conf is used for the refresh token grant
+	conf *oauth2.Config
+	// base logs in afresh; nil when only tokens were configured
+	base oauth2.TokenSource
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// tokenDiscarder is implemented by token sources that keep tokens, so
+// that a token the API refused is not handed out again
+type tokenDiscarder interface {
+	discard(accessToken string)
+}
+
+// passwordTokenSource logs in with a password on every call; tokens are
+// kept by the sources wrapping it
+type passwordTokenSource struct {
+	conf               *oauth2.Config
+	username, password string
+}
+
+func (s *passwordTokenSource) Token() (*oauth2.Token, error) {
+	return s.conf.PasswordCredentialsToken(oauth2.NoContext, s.username, s.password)
+}
+
+func (s *refreshingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	if s.token != nil && s.token.RefreshToken != "" {
+		token, err := s.conf.TokenSource(oauth2.NoContext, &oauth2.Token{RefreshToken: s.token.RefreshToken}).Token()
+		if err == nil {
+			log.Printf("[INFO] UltraDNS access token refreshed, expires %s", token.Expiry)
+			s.token = token
+			return token, nil
+		}
+		if s.base == nil {
+			return nil, fmt.Errorf("refreshing the UltraDNS access token failed: %v", err)
+		}
+		log.Printf("[WARN] refreshing the UltraDNS access token failed, logging in again: %v", err)
+	}
+	if s.base == nil {
+		if s.token == nil {
+			return nil, fmt.Errorf("no UltraDNS access token")
+		}
+		return nil, fmt.Errorf("the UltraDNS access token expired, and there is no refresh token or password to replace it with")
+	}
+	token, err := s.base.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// invalidate stops handing out accessToken, which the API refused. It
+// reports whether the caller should retry: only when the token is
+// replaceable, and not already replaced by a concurrent call.
+func (s *refreshingTokenSource) invalidate(accessToken string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil || s.token.AccessToken != accessToken {
+		// Already replaced since the request was sent
+		return s.token != nil
+	}
+	if s.token.RefreshToken == "" && s.base == nil {
+		return false
+	}
+	if d, ok := s.base.(tokenDiscarder); ok {
+		d.discard(accessToken)
+	}
+	s.token = &oauth2.Token{RefreshToken: s.token.RefreshToken}
+	return true
+}
+
+// bearerToken returns the access token of an Authorization header
+func bearerToken(header string) string {
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return header[7:]
+	}
+	return ""
+}
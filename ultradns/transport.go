@@ -0,0 +1,564 @@
+package ultradns
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// correlationIDHeader carries the ID generated for every request
+	correlationIDHeader = "X-Correlation-Id"
+	// requestIDHeader carries the ID UltraDNS assigns to a request, when
+	// it returns one
+	requestIDHeader = "X-Request-Id"
+	// maxFailures bounds the number of failed calls remembered for
+	// annotating errors
+	maxFailures = 100
+	// maxCachedResponses and maxCachedBytes bound the GET responses kept
+	// for conditional requests; the least recently used go first
+	maxCachedResponses = 256
+	maxCachedBytes     = 4 << 20
+)
+
+// transport wraps the http.RoundTripper used by udnssdk so that
+// provider-level behaviour can be applied to every API call.
+//
+// Responses are gzip-compressed: base is net/http's transport, which
+// asks for gzip and decompresses the body as long as no Accept-Encoding
+// is set on the request, so none must be set here.
+type transport struct {
+	base http.RoundTripper
+
+	// changeComment is recorded against every modifying call. None of
+	// the REST endpoints used by this provider accept a comment field,
+	// so it is written to the provider log next to the call instead.
+	changeComment string
+
+	// logJSON writes the per-call log line as a JSON object instead of
+	// plain text, for programmatic analysis of TF_LOG output
+	logJSON bool
+
+	// breakerThreshold is the number of consecutive failed calls after
+	// which every further call fails at once; 0 disables the breaker
+	breakerThreshold int
+
+	// audit, if set, records every modifying call
+	audit *auditLog
+
+	// tokens, if set, is the source of the access tokens sent by base.
+	// A call the API answers with 401 is retried once with a new token.
+	tokens *refreshingTokenSource
+
+	mu sync.Mutex
+	// cache holds GET responses that carried validators. The provider
+	// process lives for one Terraform command, so it only saves
+	// repeated reads within a run, such as a refresh followed by a plan.
+	cache responseCache
+	// retries counts consecutive failed attempts of each method and URL,
+	// so that callers retrying a call show up in the log
+	retries map[string]int
+	// failures holds the IDs of the latest failed call of each method
+	// and URL, until it succeeds
+	failures map[string]callIDs
+	// consecutiveFailures counts failed calls since the last success
+	consecutiveFailures int
+	// breakerErr is returned for every call once the breaker has tripped
+	breakerErr error
+	// rateLimit is the quota reported by the latest response that had one
+	rateLimit *rateLimit
+}
+
+// rateLimit is the API quota reported in response headers
+type rateLimit struct {
+	Limit     int
+	Remaining int
+	// Reset is the header value as sent, seconds or a timestamp
+	Reset string
+}
+
+// rateLimitHeaders are the header names quota is reported under, in
+// order of preference: the common X- prefixed form and the IETF draft
+var rateLimitHeaders = [][3]string{
+	{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+	{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+}
+
+// parseRateLimit reads the quota from h, if it reports one
+func parseRateLimit(h http.Header) (*rateLimit, bool) {
+	for _, names := range rateLimitHeaders {
+		remaining, err := strconv.Atoi(h.Get(names[1]))
+		if err != nil {
+			continue
+		}
+		// The draft allows a quota policy after the limit, e.g. "100;w=60"
+		limit, _ := strconv.Atoi(strings.SplitN(h.Get(names[0]), ";", 2)[0])
+		return &rateLimit{Limit: limit, Remaining: remaining, Reset: h.Get(names[2])}, true
+	}
+	return nil, false
+}
+
+// callIDs identifies a single API call for support tickets
+type callIDs struct {
+	correlationID string
+	requestID     string
+}
+
+// String renders the IDs for an error message
+func (c callIDs) String() string {
+	if c.requestID == "" {
+		return fmt.Sprintf("correlation ID: %s", c.correlationID)
+	}
+	return fmt.Sprintf("correlation ID: %s, UltraDNS request ID: %s", c.correlationID, c.requestID)
+}
+
+// apiCall describes a single API call for the provider log
+type apiCall struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Retry      int    `json:"retry"`
+	Error      string `json:"error,omitempty"`
+
+	CorrelationID string `json:"correlation_id"`
+	RequestID     string `json:"request_id,omitempty"`
+
+	RateLimitRemaining *int `json:"rate_limit_remaining,omitempty"`
+	// Compressed is set when the response body was sent gzip-compressed
+	Compressed bool `json:"compressed,omitempty"`
+}
+
+// cachedResponse holds the validators and body of a GET response so
+// that repeated reads of the same URL can be made conditional
+type cachedResponse struct {
+	key          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// responseCache is a least recently used cache of GET responses, keyed
+// by URL and bounded by maxCachedResponses and maxCachedBytes. The
+// caller holds transport.mu.
+type responseCache struct {
+	lru   list.List
+	byKey map[string]*list.Element
+	bytes int
+}
+
+func (c *responseCache) get(key string) *cachedResponse {
+	e, ok := c.byKey[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedResponse)
+}
+
+func (c *responseCache) put(r *cachedResponse) {
+	if c.byKey == nil {
+		c.byKey = map[string]*list.Element{}
+	}
+	c.remove(r.key)
+	c.byKey[r.key] = c.lru.PushFront(r)
+	c.bytes += len(r.body)
+	for c.lru.Len() > maxCachedResponses || c.bytes > maxCachedBytes {
+		c.remove(c.lru.Back().Value.(*cachedResponse).key)
+	}
+}
+
+func (c *responseCache) remove(key string) {
+	if e, ok := c.byKey[key]; ok {
+		c.lru.Remove(e)
+		delete(c.byKey, key)
+		c.bytes -= len(e.Value.(*cachedResponse).body)
+	}
+}
+
+// isCacheableURL reports whether GET responses from u are kept for
+// conditional requests. Listings are not: they are read once per run,
+// page by page, and decodeRRSetPage streams them, which buffering
+// them here would defeat.
+func isCacheableURL(u *url.URL) bool {
+	v := u.Query()
+	for _, k := range []string{"offset", "q", "cursor"} {
+		if _, ok := v[k]; ok {
+			return false
+		}
+	}
+	return !strings.HasSuffix(u.Path, "/rrsets") && !strings.Contains(u.Path, "/rrsets/ANY")
+}
+
+// response rebuilds a 200 response to req from the cached copy and the
+// headers of the 304 that revalidated it, which carry the current
+// quota and request ID
+func (c *cachedResponse) response(req *http.Request, notModified http.Header) *http.Response {
+	header := c.header.Clone()
+	for k, v := range notModified {
+		if k != "Content-Length" {
+			header[k] = v
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// withoutCallHeaders returns a copy of h for the cache, without the
+// headers that describe one call rather than the resource, so that a
+// cached response never reports an old quota or request ID
+func withoutCallHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	for _, names := range rateLimitHeaders {
+		for _, name := range names {
+			h.Del(name)
+		}
+	}
+	h.Del(requestIDHeader)
+	return h
+}
+
+// escapePath sends the percent-encoded segments udnssdk puts in
+// u.Path, such as the owner names of RRSetKeys, encoded rather than
+// escaping their "%" again: it decodes them into Path and sets RawPath.
+func escapePath(u *url.URL) {
+	if u.RawPath != "" || !strings.Contains(u.Path, "%") {
+		return
+	}
+	segs := strings.Split(u.Path, "/")
+	raw := make([]string, len(segs))
+	for i, seg := range segs {
+		s, err := url.PathUnescape(seg)
+		if err != nil {
+			return
+		}
+		segs[i], raw[i] = s, url.PathEscape(s)
+	}
+	u.Path, u.RawPath = strings.Join(segs, "/"), strings.Join(raw, "/")
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isMutatingRequest(req) && t.changeComment != "" {
+		log.Printf("[INFO] UltraDNS %s %s change_comment: %s", req.Method, req.URL.Path, t.changeComment)
+	}
+
+	t.mu.Lock()
+	breakerErr := t.breakerErr
+	t.mu.Unlock()
+	if breakerErr != nil {
+		return nil, breakerErr
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(correlationIDHeader, newCorrelationID())
+	escapePath(req.URL)
+
+	var payload []byte
+	if t.audit != nil && isMutatingRequest(req) && req.Body != nil {
+		var err error
+		payload, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	}
+
+	start := time.Now()
+	resp, err := t.send(req)
+	t.logCall(req, resp, err, time.Since(start))
+	if retry := t.retryUnauthorized(req, resp, err); retry != nil {
+		log.Printf("[INFO] UltraDNS refused the access token for %s %s, retrying with a new one", req.Method, req.URL.Path)
+		resp.Body.Close()
+		req = retry
+		start = time.Now()
+		resp, err = t.send(req)
+		t.logCall(req, resp, err, time.Since(start))
+	}
+	// sendBatch records each call of a batch that was answered instead
+	if t.audit != nil && isMutatingRequest(req) && !(isBatchRequest(req) && err == nil && resp.StatusCode < 300) {
+		if aerr := t.audit.record(req, payload, resp, err, t.changeComment); aerr != nil {
+			log.Printf("[ERROR] writing %s %s to the audit file %s failed: %v", req.Method, req.URL.Path, t.audit.path, aerr)
+		}
+	}
+	return resp, err
+}
+
+// send performs a single call
+func (t *transport) send(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet {
+		return t.conditionalGet(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// retryUnauthorized returns the request to send again when the API
+// refused the access token of req, typically because it expired during
+// a long apply, and a new token can be had; otherwise it returns nil.
+// The API acts on no call it answers with 401, so modifying calls are
+// retried too.
+func (t *transport) retryUnauthorized(req *http.Request, resp *http.Response, err error) *http.Request {
+	if t.tokens == nil || err != nil || resp.StatusCode != http.StatusUnauthorized || resp.Request == nil {
+		return nil
+	}
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		retry.Body = body
+	}
+	if !t.tokens.invalidate(bearerToken(resp.Request.Header.Get("Authorization"))) {
+		return nil
+	}
+	retry.Header.Set(correlationIDHeader, newCorrelationID())
+	return retry
+}
+
+// logCall writes a [DEBUG] line describing a finished API call
+func (t *transport) logCall(req *http.Request, resp *http.Response, err error, d time.Duration) {
+	c := apiCall{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		DurationMS: int64(d / time.Millisecond),
+
+		CorrelationID: req.Header.Get(correlationIDHeader),
+	}
+	var rl *rateLimit
+	if resp != nil {
+		c.Status = resp.StatusCode
+		c.RequestID = resp.Header.Get(requestIDHeader)
+		c.Compressed = resp.Uncompressed
+		if l, ok := parseRateLimit(resp.Header); ok {
+			rl = l
+			c.RateLimitRemaining = &l.Remaining
+		}
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	failed := err != nil || c.Status == http.StatusTooManyRequests || c.Status >= 500
+
+	key := fmt.Sprintf("%s %s", req.Method, req.URL.String())
+	t.mu.Lock()
+	if rl != nil {
+		t.rateLimit = rl
+	}
+	if t.retries == nil {
+		t.retries = make(map[string]int)
+	}
+	c.Retry = t.retries[key]
+	if failed {
+		t.retries[key] = c.Retry + 1
+		t.consecutiveFailures++
+		t.tripBreaker(c)
+	} else {
+		delete(t.retries, key)
+		t.consecutiveFailures = 0
+	}
+	if t.failures == nil {
+		t.failures = make(map[string]callIDs)
+	}
+	if err != nil || c.Status >= 400 {
+		if _, ok := t.failures[key]; !ok && len(t.failures) >= maxFailures {
+			for k := range t.failures {
+				delete(t.failures, k)
+				break
+			}
+		}
+		t.failures[key] = callIDs{correlationID: c.CorrelationID, requestID: c.RequestID}
+	} else {
+		delete(t.failures, key)
+	}
+	t.mu.Unlock()
+
+	if t.logJSON {
+		b, _ := json.Marshal(c)
+		log.Printf("[DEBUG] %s", b)
+		return
+	}
+	msg := fmt.Sprintf("[DEBUG] UltraDNS %s %s: status %d in %dms, correlation ID %s", c.Method, c.Path, c.Status, c.DurationMS, c.CorrelationID)
+	if c.RequestID != "" {
+		msg = fmt.Sprintf("%s, UltraDNS request ID %s", msg, c.RequestID)
+	}
+	if rl != nil {
+		msg = fmt.Sprintf("%s, rate limit remaining %d of %d", msg, rl.Remaining, rl.Limit)
+	}
+	if c.Compressed {
+		msg = fmt.Sprintf("%s, gzip", msg)
+	}
+	if c.Retry > 0 {
+		msg = fmt.Sprintf("%s, retry %d", msg, c.Retry)
+	}
+	if c.Error != "" {
+		msg = fmt.Sprintf("%s, error: %s", msg, c.Error)
+	}
+	log.Print(msg)
+}
+
+// tripBreaker opens the circuit breaker once the threshold of
+// consecutive failures is reached, so that the remaining operations of a
+// run fail fast with one diagnostic instead of each timing out in turn.
+// The caller holds t.mu.
+func (t *transport) tripBreaker(last apiCall) {
+	if t.breakerThreshold <= 0 || t.consecutiveFailures < t.breakerThreshold || t.breakerErr != nil {
+		return
+	}
+	cause := last.Error
+	if cause == "" {
+		cause = fmt.Sprintf("status %d", last.Status)
+	}
+	t.breakerErr = fmt.Errorf("not calling the UltraDNS API: the last %d calls failed, most recently %s %s with %s (correlation ID: %s); "+
+		"check the API status and credentials, then run again",
+		t.consecutiveFailures, last.Method, last.Path, cause, last.CorrelationID)
+	log.Printf("[ERROR] UltraDNS circuit breaker tripped: %v", t.breakerErr)
+}
+
+// lastRateLimit returns the latest quota reported by the API, if any
+func (t *transport) lastRateLimit() (rateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rateLimit == nil {
+		return rateLimit{}, false
+	}
+	return *t.rateLimit, true
+}
+
+// annotate appends the IDs of the failed call that err describes, if
+// any. udnssdk errors begin with the method and URL of the call, which
+// is how the call is found even when operations run concurrently.
+func (t *transport) annotate(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, ids := range t.failures {
+		if strings.Contains(msg, key) {
+			return fmt.Errorf("%v (%s)", err, ids)
+		}
+	}
+	return err
+}
+
+// newCorrelationID returns a random ID for a single request
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// conditionalGet performs a GET, sending If-None-Match/If-Modified-Since
+// when an earlier response supplied an ETag or Last-Modified, and
+// answering from the cache when the API reports 304 Not Modified.
+func (t *transport) conditionalGet(req *http.Request) (*http.Response, error) {
+	if !isCacheableURL(req.URL) {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+
+	t.mu.Lock()
+	cached := t.cache.get(key)
+	t.mu.Unlock()
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		log.Printf("[DEBUG] UltraDNS GET %s not modified, using cached response", req.URL.Path)
+		return cached.response(req, resp.Header), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") || resp.ContentLength > maxCachedBytes {
+		return resp, nil
+	}
+
+	// Read no more than fits in the cache; the rest of a larger body is
+	// passed on as it is
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCachedBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedBytes {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	t.cache.put(&cachedResponse{
+		key:          key,
+		etag:         etag,
+		lastModified: lastModified,
+		header:       withoutCallHeaders(resp.Header),
+		body:         body,
+	})
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// isBatchRequest reports whether req is a POST v1/batch request
+func isBatchRequest(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/v1/batch")
+}
+
+// isMutatingRequest reports whether req modifies state at UltraDNS
+func isMutatingRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
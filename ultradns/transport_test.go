@@ -0,0 +1,292 @@
+package ultradns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+func TestTransport_conditionalGet(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(100-hits))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"rrsets":[]}`))
+	}))
+	defer ts.Close()
+
+	tr := &transport{base: http.DefaultTransport}
+	c := &http.Client{Transport: tr}
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(ts.URL + "/v1/zones/example.com.")
+		if err != nil {
+			t.Fatalf("request %d: %s", i, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("request %d: status = %d, want 200", i, resp.StatusCode)
+		}
+		if string(body) != `{"rrsets":[]}` {
+			t.Errorf("request %d: body = %q", i, body)
+		}
+	}
+
+	if hits != 2 {
+		t.Errorf("server hits = %d, want 2", hits)
+	}
+	// The quota is the one the 304 reported, not the cached response's
+	if rl, ok := tr.lastRateLimit(); !ok || rl.Remaining != 98 {
+		t.Errorf("rate limit after a 304 = %+v, %v, want 98 remaining", rl, ok)
+	}
+}
+
+func TestTransport_conditionalGetBounds(t *testing.T) {
+	big := strings.Repeat("x", maxCachedBytes+1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/v1/big" {
+			// No Content-Length, so the size shows only while reading
+			w.(http.Flusher).Flush()
+			w.Write([]byte(big))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	tr := &transport{base: http.DefaultTransport}
+	c := &http.Client{Transport: tr}
+	get := func(path string) string {
+		resp, err := c.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %s", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	for _, path := range []string{
+		"/v1/zones/example.com./rrsets",
+		"/v1/zones/example.com./rrsets/ANY",
+		"/v1/zones/example.com./rrsets/A?offset=100",
+		"/v3/zones?q=name%3Aexample",
+	} {
+		get(path)
+	}
+	if n := tr.cache.lru.Len(); n != 0 {
+		t.Errorf("listings: %d responses cached, want none", n)
+	}
+
+	if body := get("/v1/big"); body != big {
+		t.Errorf("large body: got %d bytes, want %d", len(body), len(big))
+	}
+	if n := tr.cache.lru.Len(); n != 0 {
+		t.Errorf("large body: %d responses cached, want none", n)
+	}
+
+	for i := 0; i <= maxCachedResponses; i++ {
+		get(fmt.Sprintf("/v1/zones/z%d.example.", i))
+	}
+	if n := tr.cache.lru.Len(); n != maxCachedResponses {
+		t.Errorf("%d responses cached, want %d", n, maxCachedResponses)
+	}
+	if tr.cache.get(ts.URL+"/v1/zones/z0.example.") != nil {
+		t.Error("the least recently used response was not evicted")
+	}
+}
+
+func TestTransport_gzip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mockRespond(w, r, http.StatusOK, map[string]string{"zoneName": "example.com."})
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	c := &http.Client{Transport: &transport{base: http.DefaultTransport, logJSON: true}}
+	resp, err := c.Get(ts.URL + "/v1/zones/example.com./rrsets")
+	if err != nil {
+		t.Fatalf("request: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if strings.TrimSpace(string(body)) != `{"zoneName":"example.com."}` {
+		t.Errorf("body = %q, want it decompressed", body)
+	}
+	if !strings.Contains(buf.String(), `"compressed":true`) {
+		t.Errorf("log = %q, want the call logged as compressed", buf.String())
+	}
+}
+
+func TestTransport_logJSON(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	c := &http.Client{Transport: &transport{base: http.DefaultTransport, logJSON: true}}
+	for _, s := range []int{http.StatusServiceUnavailable, http.StatusOK} {
+		status = s
+		resp, err := c.Post(ts.URL+"/v1/zones/example.com./rrsets/A/www", "application/json", nil)
+		if err != nil {
+			t.Fatalf("request: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	calls := []apiCall{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		i := strings.Index(line, "[DEBUG] ")
+		if i < 0 {
+			continue
+		}
+		var c apiCall
+		if err := json.Unmarshal([]byte(line[i+len("[DEBUG] "):]), &c); err != nil {
+			t.Fatalf("log line %q is not JSON: %s", line, err)
+		}
+		calls = append(calls, c)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("logged %d calls, want 2: %q", len(calls), buf.String())
+	}
+	if calls[0].Method != "POST" || calls[0].Path != "/v1/zones/example.com./rrsets/A/www" || calls[0].Status != 503 || calls[0].Retry != 0 {
+		t.Errorf("first call = %+v", calls[0])
+	}
+	if calls[1].Status != 200 || calls[1].Retry != 1 {
+		t.Errorf("second call = %+v, want status 200, retry 1", calls[1])
+	}
+}
+
+func TestTransport_annotate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(correlationIDHeader) == "" {
+			t.Errorf("request without %s header", correlationIDHeader)
+		}
+		w.Header().Set(requestIDHeader, "req-1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`[{"errorCode":2111,"errorMessage":"Invalid rdata"}]`))
+	}))
+	defer ts.Close()
+
+	tr := &transport{base: http.DefaultTransport}
+	c := &http.Client{Transport: tr}
+	req, _ := http.NewRequest("PUT", ts.URL+"/v1/zones/example.com./rrsets/A/www", nil)
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("request: %s", err)
+	}
+	err = tr.annotate(udnssdk.CheckResponse(resp))
+
+	if err == nil || !strings.Contains(err.Error(), "correlation ID: ") || !strings.Contains(err.Error(), "UltraDNS request ID: req-1") {
+		t.Errorf("annotated error = %v", err)
+	}
+	if other := tr.annotate(fmt.Errorf("unrelated")); other.Error() != "unrelated" {
+		t.Errorf("unrelated error annotated: %v", other)
+	}
+}
+
+func TestTransport_circuitBreaker(t *testing.T) {
+	hits := 0
+	status := http.StatusServiceUnavailable
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	c := &http.Client{Transport: &transport{base: http.DefaultTransport, breakerThreshold: 3}}
+	get := func() error {
+		resp, err := c.Get(ts.URL + "/v1/zones/example.com./rrsets")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// A success resets the count
+	for _, s := range []int{503, 503, 200, 503, 503} {
+		status = s
+		if err := get(); err != nil {
+			t.Fatalf("status %d: unexpected error %s", s, err)
+		}
+	}
+	if err := get(); err != nil {
+		t.Fatalf("third consecutive failure: unexpected error %s", err)
+	}
+
+	status = http.StatusOK
+	err := get()
+	if err == nil || !strings.Contains(err.Error(), "the last 3 calls failed") {
+		t.Errorf("after tripping: got %v", err)
+	}
+	if hits != 6 {
+		t.Errorf("server hits = %d, want 6", hits)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	h := http.Header{}
+	if _, ok := parseRateLimit(h); ok {
+		t.Error("no headers: expected no rate limit")
+	}
+
+	h.Set("X-RateLimit-Limit", "100")
+	h.Set("X-RateLimit-Remaining", "42")
+	h.Set("X-RateLimit-Reset", "30")
+	rl, ok := parseRateLimit(h)
+	if !ok || rl.Limit != 100 || rl.Remaining != 42 || rl.Reset != "30" {
+		t.Errorf("X- headers: got %+v, %v", rl, ok)
+	}
+
+	h = http.Header{}
+	h.Set("RateLimit-Limit", "100;w=60")
+	h.Set("RateLimit-Remaining", "7")
+	rl, ok = parseRateLimit(h)
+	if !ok || rl.Limit != 100 || rl.Remaining != 7 {
+		t.Errorf("draft headers: got %+v, %v", rl, ok)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	r := rRSetResource{OwnerName: "0/25", Zone: "2.0.192.in-addr.arpa", RRType: "PTR"}
+	u := &url.URL{Path: "/v1/" + r.RRSetKey().URI()}
+	escapePath(u)
+	if want := "/v1/zones/2.0.192.in-addr.arpa/rrsets/PTR/0%2F25"; u.EscapedPath() != want {
+		t.Errorf("got %q, want %q", u.EscapedPath(), want)
+	}
+
+	u = &url.URL{Path: "/v1/zones/example.com/rrsets/A/www"}
+	escapePath(u)
+	if u.RawPath != "" || u.Path != "/v1/zones/example.com/rrsets/A/www" {
+		t.Errorf("unescaped path changed: %+v", u)
+	}
+}
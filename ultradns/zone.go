@@ -0,0 +1,246 @@
+package ultradns
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/terra-farm/udnssdk"
+)
+
+// udnssdk has no Zones service, so the zone endpoints used by this
+// provider are described here.
+
+// zoneProperties wraps the properties object of a zone response
+type zoneProperties struct {
+	Name                string `json:"name"`
+	AccountName         string `json:"accountName"`
+	Type                string `json:"type"`
+	DNSSECStatus        string `json:"dnssecStatus"`
+	Status              string `json:"status"`
+	Owner               string `json:"owner"`
+	ResourceRecordCount int    `json:"resourceRecordCount"`
+	LastModified        string `json:"lastModifiedDateTime"`
+}
+
+// transferStatusDetails wraps the zone transfer status of a secondary
+// zone response
+type transferStatusDetails struct {
+	LastRefresh              string `json:"lastRefresh"`
+	NextRefresh              string `json:"nextRefresh"`
+	LastRefreshStatus        string `json:"lastRefreshStatus"`
+	LastRefreshStatusMessage string `json:"lastRefreshStatusMessage"`
+}
+
+// zoneDTO wraps a zone response
+type zoneDTO struct {
+	Properties zoneProperties `json:"properties"`
+	// TransferStatusDetails is only returned for secondary zones
+	TransferStatusDetails *transferStatusDetails `json:"transferStatusDetails,omitempty"`
+}
+
+// cursorInfo wraps the paging metadata of a cursor-based index response
+type cursorInfo struct {
+	First    string `json:"first"`
+	Previous string `json:"previous"`
+	Next     string `json:"next"`
+	Last     string `json:"last"`
+}
+
+// zoneListDTO wraps a page of the v3 zones index
+type zoneListDTO struct {
+	Zones      []zoneDTO  `json:"zones"`
+	CursorInfo cursorInfo `json:"cursorInfo"`
+}
+
+// zonesPageLimit is the largest page the v3 zones index will return
+const zonesPageLimit = 1000
+
+// zonesQueryURI generates the v3 zones index URI for a name filter and
+// cursor, either of which may be empty
+func zonesQueryURI(nameFilter, cursor string) string {
+	v := url.Values{}
+	v.Set("limit", fmt.Sprintf("%d", zonesPageLimit))
+	if nameFilter != "" {
+		v.Set("q", fmt.Sprintf("name:%s", nameFilter))
+	}
+	if cursor != "" {
+		v.Set("cursor", cursor)
+	}
+	return fmt.Sprintf("zones?%s", v.Encode())
+}
+
+// selectZones streams every zone matching nameFilter to fn, one page at
+// a time, following the cursor API so the full index is never held in
+// memory at once
+func selectZones(client *Client, nameFilter string, fn func(zoneDTO) error) error {
+	cursor := ""
+	for {
+		var page zoneListDTO
+		_, err := doV3(client, "GET", zonesQueryURI(nameFilter, cursor), &page)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] selectZones(%q): %d zones, next cursor: %q", nameFilter, len(page.Zones), page.CursorInfo.Next)
+		for _, z := range page.Zones {
+			if err := fn(z); err != nil {
+				return err
+			}
+		}
+		if page.CursorInfo.Next == "" || len(page.Zones) == 0 {
+			return nil
+		}
+		cursor = page.CursorInfo.Next
+	}
+}
+
+// findZone reads the properties of a single zone
+func findZone(client *Client, zone string) (zoneDTO, error) {
+	var z zoneDTO
+	_, err := client.Do("GET", fmt.Sprintf("zones/%s", zone), nil, &z)
+	return z, err
+}
+
+// findZoneSOA returns the rdata of the zone's SOA record as served by
+// UltraDNS
+func findZoneSOA(client *Client, zone string) (string, error) {
+	rrsets, err := selectRRSets(client, rRSetQuery{Zone: zone, Type: "SOA"})
+	if err != nil {
+		return "", err
+	}
+	if len(rrsets) == 0 || len(rrsets[0].RData) == 0 {
+		return "", fmt.Errorf("zone %q has no SOA record", zone)
+	}
+	return rrsets[0].RData[0], nil
+}
+
+// findZoneSerial returns the serial of the zone's SOA record
+func findZoneSerial(client *Client, zone string) (int, error) {
+	soa, err := findZoneSOA(client, zone)
+	if err != nil {
+		return 0, err
+	}
+	return parseSOASerial(soa)
+}
+
+// findZoneDefaultTTL returns the minimum field of the zone's SOA record,
+// which records that don't set their own TTL follow
+func findZoneDefaultTTL(client *Client, zone string) (int, error) {
+	soa, err := findZoneSOA(client, zone)
+	if err != nil {
+		return 0, err
+	}
+	return parseSOAMinimum(soa)
+}
+
+// checkZoneSerial fails if the serial of zone is neither planned, the
+// serial it had when the change was planned, nor one produced by this
+// client's own writes since
+func checkZoneSerial(client *Client, zone string, planned int) error {
+	serial, err := findZoneSerial(client, zone)
+	if err != nil {
+		return fmt.Errorf("zone serial check failed: %v", err)
+	}
+	if serial == planned || client.isOwnSerial(zone, serial) {
+		return nil
+	}
+	return fmt.Errorf("zone %q was changed since this change was planned: its serial is %d, not %d. "+
+		"Run terraform plan again to review the out-of-band changes before applying", zone, serial, planned)
+}
+
+// rememberZoneSerial records the serial of zone after a write by this
+// client, so checkZoneSerial doesn't mistake it for an out-of-band change
+func rememberZoneSerial(client *Client, zone string) {
+	serial, err := findZoneSerial(client, zone)
+	if err != nil {
+		log.Printf("[WARN] reading the serial of zone %q after a write failed: %v", zone, err)
+		return
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	key := zoneSerialKey(zone)
+	if client.ownSerials == nil {
+		client.ownSerials = make(map[string]map[int]bool)
+	}
+	if client.ownSerials[key] == nil {
+		client.ownSerials[key] = make(map[int]bool)
+	}
+	client.ownSerials[key][serial] = true
+}
+
+func (c *Client) isOwnSerial(zone string, serial int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ownSerials[zoneSerialKey(zone)][serial]
+}
+
+func zoneSerialKey(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, "."))
+}
+
+// parseSOASerial extracts the serial from SOA rdata, which is
+// "mname rname serial refresh retry expire minimum"
+func parseSOASerial(rdata string) (int, error) {
+	fields := strings.Fields(rdata)
+	if len(fields) < 3 {
+		return 0, fmt.Errorf("malformed SOA rdata: %q", rdata)
+	}
+	serial, err := strconv.ParseUint(fields[2], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("malformed SOA serial in %q: %v", rdata, err)
+	}
+	return int(serial), nil
+}
+
+// parseSOAMinimum extracts the minimum, the last field, from SOA rdata
+func parseSOAMinimum(rdata string) (int, error) {
+	fields := strings.Fields(rdata)
+	if len(fields) < 7 {
+		return 0, fmt.Errorf("malformed SOA rdata: %q", rdata)
+	}
+	minimum, err := strconv.ParseUint(fields[6], 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("malformed SOA minimum in %q: %v", rdata, err)
+	}
+	return int(minimum), nil
+}
+
+// doV3 sends a request to the v3 API, which udnssdk.Client.Do cannot
+// address, and decodes the JSON response into v
+func doV3(client *Client, method, pathquery string, v interface{}) (*http.Response, error) {
+	u := *client.BaseURL
+	pq := strings.SplitN(pathquery, "?", 2)
+	u.Path = u.Path + fmt.Sprintf("v3/%s", pq[0])
+	if len(pq) == 2 {
+		u.RawQuery = pq[1]
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("User-Agent", client.UserAgent)
+
+	res, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	err = udnssdk.CheckResponse(res)
+	if err != nil {
+		return res, err
+	}
+	if v != nil {
+		err = json.NewDecoder(res.Body).Decode(v)
+	}
+	return res, err
+}
@@ -0,0 +1,62 @@
+package ultradns
+
+import "testing"
+
+func TestParseSOASerial(t *testing.T) {
+	serial, err := parseSOASerial("pdns1.ultradns.net. hostmaster.example.com. 2020061501 86400 86400 86400 86400")
+	if err != nil || serial != 2020061501 {
+		t.Errorf("parseSOASerial() = %d, %v, want 2020061501", serial, err)
+	}
+
+	for _, rdata := range []string{"pdns1.ultradns.net. hostmaster.example.com.", "a. b. -1 1 1 1 1", "a. b. 4294967296 1 1 1 1"} {
+		if _, err := parseSOASerial(rdata); err == nil {
+			t.Errorf("parseSOASerial(%q): expected error", rdata)
+		}
+	}
+}
+
+func TestParseSOAMinimum(t *testing.T) {
+	minimum, err := parseSOAMinimum("pdns1.ultradns.net. hostmaster.example.com. 2020061501 86400 3600 604800 300")
+	if err != nil || minimum != 300 {
+		t.Errorf("parseSOAMinimum() = %d, %v, want 300", minimum, err)
+	}
+
+	for _, rdata := range []string{"a. b. 1 1 1 1", "a. b. 1 1 1 1 -1", "a. b. 1 1 1 1 2147483648"} {
+		if _, err := parseSOAMinimum(rdata); err == nil {
+			t.Errorf("parseSOAMinimum(%q): expected error", rdata)
+		}
+	}
+}
+
+func TestCheckZoneSerial(t *testing.T) {
+	client, err := (&Config{Username: t.Name(), Password: "pass", Mock: true}).Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	write := func(name string) {
+		r := rRSetResource{OwnerName: name, RRType: "A", Zone: "example.com", TTL: 300, RData: []string{"192.0.2.1"}}
+		if err := createRRSet(client, r, false); err != nil {
+			t.Fatalf("createRRSet: %v", err)
+		}
+	}
+
+	planned, err := findZoneSerial(client, "example.com")
+	if err != nil {
+		t.Fatalf("findZoneSerial: %v", err)
+	}
+	if err := checkZoneSerial(client, "example.com", planned); err != nil {
+		t.Errorf("unchanged zone: %v", err)
+	}
+
+	// A write by this client is not an out-of-band change
+	write("ours")
+	rememberZoneSerial(client, "example.com.")
+	if err := checkZoneSerial(client, "example.com", planned); err != nil {
+		t.Errorf("after own write: %v", err)
+	}
+
+	write("theirs")
+	if err := checkZoneSerial(client, "example.com", planned); err == nil {
+		t.Error("after out-of-band write: expected an error")
+	}
+}